@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Доступные для тест-сьютов флаги командной строки
@@ -11,4 +13,5 @@ var (
 
 func init() {
 	flag.StringVar(&flagTargetBinaryPath, "binary-path", "", "path to target script binary")
+	random.RegisterSeedFlag()
 }
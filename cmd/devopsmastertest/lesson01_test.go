@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -184,8 +185,7 @@ const (
 )
 
 func newScenarios() (res scenarios) {
-	src := rand.NewSource(time.Now().UnixNano())
-	rnd := rand.New(src)
+	rnd := random.NewRand("Lesson01")
 
 	// изначальная конфигурация сервера
 	memBytesAvailable := intInRange(rnd, 4*unitGb, 5*unitGb)
@@ -33,7 +33,7 @@ func (suite *Lesson02Suite) TestValidateYAML() {
 	// проверяем наличие необходимых флагов
 	suite.Require().NotEmpty(flagTargetBinaryPath, "-binary-path non-empty flag required")
 
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := random.NewRand(suite.T().Name())
 
 	// сгененрируем новое содержимое YAML файла
 	suite.T().Log("creating test YAML file")
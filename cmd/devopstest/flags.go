@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 var (
@@ -14,6 +16,7 @@ var (
 	flagFileStoragePath  string
 	flagDatabaseDSN      string
 	flagSHA256Key        string
+	flagTimingTolerance  float64
 )
 
 func init() {
@@ -26,4 +29,6 @@ func init() {
 	flag.StringVar(&flagFileStoragePath, "file-storage-path", "", "path to persistent file storage")
 	flag.StringVar(&flagDatabaseDSN, "database-dsn", "", "connection string to database")
 	flag.StringVar(&flagSHA256Key, "key", "", "sha256 key for hashing")
+	flag.Float64Var(&flagTimingTolerance, "timing-tolerance", 0.25, "relative tolerance for agent report interval checks")
+	random.RegisterSeedFlag()
 }
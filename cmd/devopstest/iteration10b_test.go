@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration10bSuite is a suite of autotests
@@ -39,7 +40,7 @@ func (suite *Iteration10bSuite) SetupSuite() {
 	suite.Require().NotEmpty(flagSHA256Key, "-key non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
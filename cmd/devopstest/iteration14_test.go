@@ -11,13 +11,13 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"syscall"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration14Suite is a suite of autotests
@@ -26,9 +26,8 @@ type Iteration14Suite struct {
 
 	serverAddress string
 	serverPort    string
-	serverProcess *fork.BackgroundProcess
+	processes     *fork.Supervisor
 	serverArgs    []string
-	agentProcess  *fork.BackgroundProcess
 	agentArgs     []string
 	// knownPgLibraries []string
 
@@ -48,7 +47,7 @@ func (suite *Iteration14Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagSHA256Key, "-key non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -75,127 +74,58 @@ func (suite *Iteration14Suite) SetupSuite() {
 		"-k=" + flagSHA256Key,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	suite.agentUp(ctx, suite.envs, suite.agentArgs, flagServerPort)
-	suite.serverUp(ctx, suite.envs, suite.serverArgs, flagServerPort)
-}
-
-func (suite *Iteration14Suite) serverUp(ctx context.Context, envs, args []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
-		fork.WithEnv(envs...),
-		fork.WithArgs(args...),
-	)
-
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v, флаги командной строки: %+v", p, err, envs, args)
-		return
-	}
-
-	err = p.WaitPort(ctx, "tcp", port)
-	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-		}
-		return
-	}
-	suite.serverProcess = p
-}
-
-func (suite *Iteration14Suite) agentUp(ctx context.Context, envs, args []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
-		fork.WithEnv(envs...),
-		fork.WithArgs(args...),
+	// агент запускается первым: пока сервер не запущен, мы слушаем порт и ждем от агента данных
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "agent",
+			Command: flagAgentBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(suite.envs...), fork.WithArgs(suite.agentArgs...)},
+			Ready:   []fork.ReadinessProbe{fork.ListenProbe("tcp", flagServerPort)},
+		},
+		fork.ProcessSpec{
+			Name:      "server",
+			Command:   flagServerBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(suite.envs...), fork.WithArgs(suite.serverArgs...)},
+			DependsOn: []string{"agent"},
+			Ready:     []fork.ReadinessProbe{fork.PortProbe("tcp", flagServerPort)},
+		},
 	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
 
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v, флаги командной строки: %+v", p, err, envs, args)
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-	err = p.ListenPort(ctx, "tcp", port)
+	err = processes.Start(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
-		}
+		suite.T().Errorf("Не удалось запустить процессы агента и сервера: %s. Переменные окружения: %+v, флаги командной строки агента: %+v, сервера: %+v",
+			err, suite.envs, suite.agentArgs, suite.serverArgs)
 		return
 	}
-	suite.agentProcess = p
 }
 
 // TearDownSuite teardowns suite dependencies
 func (suite *Iteration14Suite) TearDownSuite() {
-	suite.agentShutdown()
-	suite.serverShutdown()
-}
-
-func (suite *Iteration14Suite) serverShutdown() {
-	if suite.serverProcess == nil {
+	if suite.processes == nil {
 		return
 	}
 
-	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
-	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
-	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
-
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if out := suite.serverProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-	}
-	if out := suite.serverProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-	}
-}
-
-func (suite *Iteration14Suite) agentShutdown() {
-	if suite.agentProcess == nil {
-		return
-	}
-
-	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	results, err := suite.processes.Stop(ctx)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
 	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	for name, res := range results {
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
 	}
 
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	if out := suite.agentProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-	}
-	if out := suite.agentProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
 	}
 }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration3bSuite is a suite of autotests
@@ -25,6 +26,8 @@ type Iteration3bSuite struct {
 
 	serverAddress string
 	serverProcess *fork.BackgroundProcess
+
+	rnd *rand.Rand
 }
 
 // SetupSuite bootstraps suite dependencies
@@ -32,6 +35,8 @@ func (suite *Iteration3bSuite) SetupSuite() {
 	// check required flags
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 
+	suite.rnd = random.NewRand(suite.T().Name())
+
 	suite.serverAddress = "http://localhost:8080"
 
 	envs := append(os.Environ(), []string{
@@ -114,10 +119,10 @@ func (suite *Iteration3bSuite) TestGauge() {
 
 	count := 3
 	suite.Run("update sequence", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		for i := 0; i < count; i++ {
-			v := strings.TrimRight(fmt.Sprintf("%.3f", rand.Float64()*1000000), "0")
+			v := strings.TrimRight(fmt.Sprintf("%.3f", suite.rnd.Float64()*1000000), "0")
 			resp, err := req.Post("update/gauge/testSetGet" + id + "/" + v)
 			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос с обновлением gauge")
 
@@ -145,7 +150,7 @@ func (suite *Iteration3bSuite) TestGauge() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/gauge/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения gauge")
@@ -178,7 +183,7 @@ func (suite *Iteration3bSuite) TestCounter() {
 
 	suite.Run("update sequence", func() {
 		req := httpc.R()
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		resp, err := req.Get("value/counter/testSetGet" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
 
@@ -191,7 +196,7 @@ func (suite *Iteration3bSuite) TestCounter() {
 		a, _ := strconv.ParseInt(resp.String(), 0, 64)
 
 		for i := 0; i < count; i++ {
-			v := rand.Intn(1024)
+			v := suite.rnd.Intn(1024)
 			a += int64(v)
 			resp, err = req.Post("update/counter/testSetGet" + id + "/" + strconv.Itoa(v))
 
@@ -221,7 +226,7 @@ func (suite *Iteration3bSuite) TestCounter() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/counter/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
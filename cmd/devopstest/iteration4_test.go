@@ -8,13 +8,13 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"syscall"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration4Suite is a suite of autotests
@@ -22,8 +22,7 @@ type Iteration4Suite struct {
 	suite.Suite
 
 	serverAddress string
-	serverProcess *fork.BackgroundProcess
-	agentProcess  *fork.BackgroundProcess
+	processes     *fork.Supervisor
 
 	knownEncodingLibs []string
 
@@ -45,7 +44,7 @@ func (suite *Iteration4Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 
 	suite.knownEncodingLibs = []string{
 		"encoding/json",
@@ -59,125 +58,57 @@ func (suite *Iteration4Suite) SetupSuite() {
 		"RESTORE=false",
 		// "KEY=hohoho",
 	}...)
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	suite.agentUp(ctx, envs, "8080")
-	suite.serverUp(ctx, envs, "8080")
-}
-
-func (suite *Iteration4Suite) serverUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
-		fork.WithEnv(envs...),
-	)
-
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
-		return
-	}
-
-	err = p.WaitPort(ctx, "tcp", port)
-	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-		}
-		return
-	}
-	suite.serverProcess = p
-}
-
-func (suite *Iteration4Suite) agentUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
-		fork.WithEnv(envs...),
+	// агент запускается первым: пока сервер не запущен, мы слушаем порт и ждем от агента данных
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "agent",
+			Command: flagAgentBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(envs...)},
+			Ready:   []fork.ReadinessProbe{fork.ListenProbe("tcp", "8080")},
+		},
+		fork.ProcessSpec{
+			Name:      "server",
+			Command:   flagServerBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(envs...)},
+			DependsOn: []string{"agent"},
+			Ready:     []fork.ReadinessProbe{fork.PortProbe("tcp", "8080")},
+		},
 	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
 
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-	err = p.ListenPort(ctx, "tcp", port)
+	err = processes.Start(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
-		}
+		suite.T().Errorf("Не удалось запустить процессы агента и сервера: %s. Переменные окружения: %+v", err, envs)
 		return
 	}
-	suite.agentProcess = p
 }
 
 // TearDownSuite teardowns suite dependencies
 func (suite *Iteration4Suite) TearDownSuite() {
-	suite.agentShutdown()
-	suite.serverShutdown()
-}
-
-func (suite *Iteration4Suite) serverShutdown() {
-	if suite.serverProcess == nil {
+	if suite.processes == nil {
 		return
 	}
 
-	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
-	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
-	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
-
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if out := suite.serverProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-	}
-	if out := suite.serverProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-	}
-}
-
-func (suite *Iteration4Suite) agentShutdown() {
-	if suite.agentProcess == nil {
-		return
-	}
-
-	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	results, err := suite.processes.Stop(ctx)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
 	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	for name, res := range results {
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
 	}
 
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	if out := suite.agentProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-	}
-	if out := suite.agentProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
 	}
 }
 
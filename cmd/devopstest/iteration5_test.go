@@ -6,22 +6,25 @@ import (
 	"errors"
 	"net/http"
 	"os"
-	"syscall"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
 )
 
+// reportInterval is REPORT_INTERVAL the agent is started with
+const reportInterval = 10 * time.Second
+
 // Iteration5Suite is a suite of autotests
 type Iteration5Suite struct {
 	suite.Suite
 
 	serverAddress string
-	serverProcess *fork.BackgroundProcess
-	agentProcess  *fork.BackgroundProcess
+	processes     *fork.Supervisor
+	agentRecorder *recorder.Recorder
 }
 
 func (suite *Iteration5Suite) SetupSuite() {
@@ -30,137 +33,83 @@ func (suite *Iteration5Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
+	suite.Require().Positive(flagTimingTolerance, "-timing-tolerance positive flag required")
 
 	suite.serverAddress = "http://localhost:" + flagServerPort
 
 	envs := append(os.Environ(), []string{
 		"ADDRESS=" + "localhost:" + flagServerPort,
-		"REPORT_INTERVAL=" + "10s",
+		"REPORT_INTERVAL=" + reportInterval.String(),
 		"POLL_INTERVAL=" + "2s",
 		"RESTORE=false",
 
 		"SHUTDOWN_TIMEOUT=" + "5s",
 	}...)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	suite.agentUp(ctx, envs, flagServerPort)
-	suite.serverUp(ctx, envs, flagServerPort)
-}
-
-func (suite *Iteration5Suite) serverUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
-		fork.WithEnv(envs...),
-	)
-
-	err := p.Start(ctx)
+	// agent sends metrics through a proxy that records every request to the server
+	rec, err := recorder.New("", "localhost:"+flagServerPort)
 	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v", p, err, envs)
+		suite.T().Errorf("Не удалось запустить прокси для записи запросов агента: %s", err)
 		return
 	}
-
-	err = p.WaitPort(ctx, "tcp", port)
-	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-		}
-		return
-	}
-	suite.serverProcess = p
-}
-
-func (suite *Iteration5Suite) agentUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
-		fork.WithEnv(envs...),
+	suite.agentRecorder = rec
+
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "server",
+			Command: flagServerBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(envs...)},
+			Ready:   []fork.ReadinessProbe{fork.PortProbe("tcp", flagServerPort)},
+		},
+		fork.ProcessSpec{
+			Name:      "agent",
+			Command:   flagAgentBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(append(envs, "ADDRESS="+rec.Addr())...)},
+			DependsOn: []string{"server"},
+			Ready: []fork.ReadinessProbe{func(ctx context.Context, _ *fork.BackgroundProcess) error {
+				// ожидаем первого записанного запроса агента
+				return rec.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
+			}},
+		},
 	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
 
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-	err = p.ListenPort(ctx, "tcp", port)
+	err = processes.Start(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
-		}
+		suite.T().Errorf("Не удалось запустить процессы агента и сервера: %s. Переменные окружения: %+v", err, envs)
 		return
 	}
-	suite.agentProcess = p
 }
 
 // TearDownSuite teardowns suite dependencies
 func (suite *Iteration5Suite) TearDownSuite() {
-	suite.agentShutdown()
-	suite.serverShutdown()
-}
-
-func (suite *Iteration5Suite) serverShutdown() {
-	if suite.serverProcess == nil {
-		return
+	if suite.agentRecorder != nil {
+		defer suite.agentRecorder.Close()
 	}
-
-	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
-	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+	if suite.processes == nil {
 		return
 	}
 
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
-
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if out := suite.serverProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-	}
-	if out := suite.serverProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-	}
-}
-
-func (suite *Iteration5Suite) agentShutdown() {
-	if suite.agentProcess == nil {
-		return
-	}
-
-	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	results, err := suite.processes.Stop(ctx)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
 	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	for name, res := range results {
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
 	}
 
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	if out := suite.agentProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-	}
-	if out := suite.agentProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
 	}
 }
 
@@ -303,3 +252,23 @@ cont:
 		})
 	}
 }
+
+// TestAgentReportInterval checks that agent sends metrics with REPORT_INTERVAL period
+func (suite *Iteration5Suite) TestAgentReportInterval() {
+	suite.Require().NotNil(suite.agentRecorder, "Прокси для записи запросов агента не запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*reportInterval)
+	defer cancel()
+
+	_, err := suite.agentRecorder.WaitUpdates(ctx, "PollCount", 3)
+	suite.Require().NoError(err, "Агент не отправил три значения метрики PollCount")
+
+	updates, err := suite.agentRecorder.Updates()
+	suite.Assert().NoError(err, "Не удалось разобрать запросы агента")
+	suite.Assert().NotEmpty(updates)
+
+	intervals := suite.agentRecorder.UpdateIntervals("PollCount")
+	for _, m := range recorder.IntervalMismatches(intervals, reportInterval, flagTimingTolerance) {
+		suite.Assert().Failf("Интервал между отправками метрики PollCount не соответствует REPORT_INTERVAL", "%s", m)
+	}
+}
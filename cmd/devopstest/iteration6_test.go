@@ -8,13 +8,13 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"syscall"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration6Suite is a suite of autotests
@@ -23,8 +23,7 @@ type Iteration6Suite struct {
 
 	serverAddress string
 	serverPort    string
-	serverProcess *fork.BackgroundProcess
-	agentProcess  *fork.BackgroundProcess
+	processes     *fork.Supervisor
 	// knownPgLibraries []string
 
 	rnd  *rand.Rand
@@ -40,7 +39,7 @@ func (suite *Iteration6Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagFileStoragePath, "-file-storage-path non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	// suite.knownPgLibraries = []string{
 	// 	"database/sql",
 	// 	"github.com/jackc/pgx",
@@ -61,126 +60,92 @@ func (suite *Iteration6Suite) SetupSuite() {
 		"DATABASE_DSN=" + flagDatabaseDSN,
 	}...)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	suite.agentUp(ctx, suite.envs, flagServerPort)
-	suite.serverUp(ctx, suite.envs, flagServerPort)
-}
-
-func (suite *Iteration6Suite) serverUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
-		fork.WithEnv(envs...),
+	// агент запускается первым: пока сервер не запущен, мы слушаем порт и ждем от агента данных
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "agent",
+			Command: flagAgentBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(suite.envs...)},
+			Ready:   []fork.ReadinessProbe{fork.ListenProbe("tcp", flagServerPort)},
+		},
+		fork.ProcessSpec{
+			Name:      "server",
+			Command:   flagServerBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(suite.envs...)},
+			DependsOn: []string{"agent"},
+			Ready:     []fork.ReadinessProbe{fork.PortProbe("tcp", flagServerPort)},
+		},
 	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
 
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v", p, err, envs)
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-	err = p.WaitPort(ctx, "tcp", port)
+	err = processes.Start(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
-		}
+		suite.T().Errorf("Не удалось запустить процессы агента и сервера: %s. Переменные окружения: %+v", err, suite.envs)
 		return
 	}
-	suite.serverProcess = p
 }
 
-func (suite *Iteration6Suite) agentUp(ctx context.Context, envs []string, port string) {
-	p := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
-		fork.WithEnv(envs...),
-	)
-
-	err := p.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
+// TearDownSuite teardowns suite dependencies
+func (suite *Iteration6Suite) TearDownSuite() {
+	if suite.processes == nil {
 		return
 	}
 
-	err = p.ListenPort(ctx, "tcp", port)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results, err := suite.processes.Stop(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
-		if out := p.Stderr(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-		}
-		if out := p.Stdout(ctx); len(out) > 0 {
-			suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
+	}
+	for name, res := range results {
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
 		}
-		return
 	}
-	suite.agentProcess = p
-}
 
-// TearDownSuite teardowns suite dependencies
-func (suite *Iteration6Suite) TearDownSuite() {
-	suite.agentShutdown()
-	suite.serverShutdown()
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
+	}
 }
 
-func (suite *Iteration6Suite) serverShutdown() {
-	if suite.serverProcess == nil {
-		return
-	}
+func (suite *Iteration6Suite) serverRestart() {
+	suite.Require().NotNil(suite.processes, "Процессы агента и сервера не были запущены")
+	server := suite.processes.Process("server")
+	suite.Require().NotNil(server, "Процесс сервера не был запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
 
-	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	err := server.Restart(ctx)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		suite.T().Errorf("Не удалось перезапустить процесс командой %q: %s", server, err)
 		return
 	}
 
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	// выводим логи предыдущего запуска
+	incarnations := server.Incarnations()
+	prev := incarnations[len(incarnations)-1]
+	if prev.ExitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", prev.ExitCode)
 	}
-
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	if out := suite.serverProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог сервера:\n\n%s\n\n", string(out))
+	if len(prev.Stderr) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(prev.Stderr))
 	}
-	if out := suite.serverProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог сервера:\n\n%s\n\n", string(out))
+	if len(prev.Stdout) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(prev.Stdout))
 	}
-}
 
-func (suite *Iteration6Suite) agentShutdown() {
-	if suite.agentProcess == nil {
-		return
-	}
-
-	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	err = server.WaitPort(ctx, "tcp", suite.serverPort)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", suite.serverPort, err)
 		return
 	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
-
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	if out := suite.agentProcess.Stderr(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог агента:\n\n%s\n\n", string(out))
-	}
-	if out := suite.agentProcess.Stdout(ctx); len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог агента:\n\n%s\n\n", string(out))
-	}
 }
 
 func (suite *Iteration6Suite) TestCounterHandlers() {
@@ -279,10 +244,7 @@ func (suite *Iteration6Suite) TestCounterHandlers() {
 
 	suite.Run("restart server", func() {
 		time.Sleep(5 * time.Second) // relax time
-		suite.serverShutdown()
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		suite.serverUp(ctx, suite.envs, suite.serverPort)
+		suite.serverRestart()
 	})
 
 	suite.Run("get", func() {
@@ -382,10 +344,7 @@ func (suite *Iteration6Suite) TestGaugeHandlers() {
 
 	suite.Run("restart server", func() {
 		time.Sleep(5 * time.Second) // relax time
-		suite.serverShutdown()
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		suite.serverUp(ctx, suite.envs, suite.serverPort)
+		suite.serverRestart()
 	})
 
 	suite.Run("get", func() {
@@ -1,6 +1,10 @@
 package main
 
-import "flag"
+import (
+	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
 
 var (
 	flagServerBinaryPath string // путь до бинарного файла сервера
@@ -8,4 +12,5 @@ var (
 
 func init() {
 	flag.StringVar(&flagServerBinaryPath, "server-binary-path", "", "path to server binary")
+	random.RegisterSeedFlag()
 }
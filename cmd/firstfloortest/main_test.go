@@ -3,13 +3,21 @@ package main
 //go:generate go test -c -o=../../bin/firstfloortest
 
 import (
+	"flag"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// инициализируем генераторы псевдослучайных значений, чтобы запуск можно было повторить
+	random.SetupSeed()
+
 	os.Exit(m.Run())
 }
 
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"errors"
-	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -18,6 +17,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Sprint6FinalSuite struct {
@@ -135,10 +135,11 @@ func (suite *Sprint6FinalSuite) TestSprint6Final() {
 		//defer os.RemoveAll(tmpDir)
 
 		alphabeth := []rune("АБВГДЕЖЗИЙКЛМНОПРСТУФХЦЧШЩЫЭЮЯ")
-		length := rand.Intn(20) + 10
+		rnd := random.NewRand(suite.T().Name())
+		length := rnd.Intn(20) + 10
 		text := make([]rune, length)
 		for i := range text {
-			text[i] = alphabeth[rand.Intn(len(alphabeth))]
+			text[i] = alphabeth[rnd.Intn(len(alphabeth))]
 		}
 		originalText := string(text)
 
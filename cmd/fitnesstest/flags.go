@@ -0,0 +1,7 @@
+package ftracker
+
+import "github.com/Yandex-Practicum/go-autotests/internal/random"
+
+func init() {
+	random.RegisterSeedFlag()
+}
@@ -1,15 +1,27 @@
 package ftracker
 
 import (
+	"flag"
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// инициализируем генераторы псевдослучайных значений, чтобы запуск можно было повторить
+	random.SetupSeed()
+
+	os.Exit(m.Run())
+}
+
 func TestFitnessSuite(t *testing.T) {
 	suite.Run(t, new(FitnessSuite))
 }
@@ -19,7 +31,7 @@ type FitnessSuite struct {
 }
 
 func (s *FitnessSuite) TestShowTrainingInfo() {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := random.NewRand(s.T().Name())
 
 	actionsNum := int(rnd.Int63n(10000-1000) + 1000)
 	durationNum := float64(rnd.Int63n(3)) + rnd.Float64()
@@ -66,7 +78,7 @@ func (s *FitnessSuite) TestShowTrainingInfo() {
 
 	s.Run("unknown", func() {
 		actionsNum := int(rnd.Int63n(10000-1000) + 1000)
-		trainingType := randString(3, 15)
+		trainingType := randString(rnd, 3, 15)
 		durationNum := float64(rnd.Int63n(3)) + rnd.Float64()
 		weightNum := float64(rnd.Int63n(140-80) + 80)
 		heightNum := float64(rnd.Int63n(220-150) + 150)
@@ -79,7 +91,7 @@ func (s *FitnessSuite) TestShowTrainingInfo() {
 }
 
 func (s *FitnessSuite) TestWalkingSpentCalories() {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := random.NewRand(s.T().Name())
 
 	actionsNum := int(rnd.Int63n(10000-1000) + 1000)
 	durationNum := float64(rnd.Int63n(3)) + rnd.Float64()
@@ -94,7 +106,7 @@ func (s *FitnessSuite) TestWalkingSpentCalories() {
 }
 
 func (s *FitnessSuite) TestRunningSpentCalories() {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := random.NewRand(s.T().Name())
 
 	actionsNum := int(rnd.Int63n(10000-1000) + 1000)
 	durationNum := float64(rnd.Int63n(3)) + rnd.Float64()
@@ -108,7 +120,7 @@ func (s *FitnessSuite) TestRunningSpentCalories() {
 }
 
 func (s *FitnessSuite) TestSwimmingSpentCalories() {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rnd := random.NewRand(s.T().Name())
 
 	lengthPoolNum := int(rnd.Int63n(50-10) + 10)
 	countPoolNum := int(rnd.Int63n(10-1) + 1)
@@ -122,10 +134,9 @@ func (s *FitnessSuite) TestSwimmingSpentCalories() {
 	s.Assert().InDelta(expected, res, 0.05, "Значение полученное из функции SwimmingSpentCalories не совпадает с ожидаемым")
 }
 
-func randString(minLen, maxLen int) string {
+func randString(rnd *rand.Rand, minLen, maxLen int) string {
 	var letters = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFJHIJKLMNOPQRSTUVWXYZ"
 
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
 	slen := rnd.Intn(maxLen-minLen) + minLen
 
 	s := make([]byte, 0, slen)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"os"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // AccrualSuite is a suite of autotests
@@ -29,19 +31,30 @@ func (suite *AccrualSuite) SetupSuite() {
 	suite.Require().NotEmpty(flagAccrualBinaryPath, "-accrual-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagAccrualDatabaseURI, "-accrual-database-uri non-empty flag required")
 	suite.Require().NotEmpty(flagAccrualHost, "-accrual-host non-empty flag required")
-	suite.Require().NotEmpty(flagAccrualPort, "-accrual-port non-empty flag required")
 
 	// start accrual server
 	{
-		suite.accrualServerAddress = "http://" + flagAccrualHost + ":" + flagAccrualPort
-
 		envs := append(os.Environ(),
-			"RUN_ADDRESS="+flagAccrualHost+":"+flagAccrualPort,
 			"DATABASE_URI="+flagAccrualDatabaseURI,
 		)
-		p := fork.NewBackgroundProcess(context.Background(), flagAccrualBinaryPath,
-			fork.WithEnv(envs...),
-		)
+
+		// if no port is given, lease a free one so that suites can run side by side
+		opts := []fork.ProcessOpt{fork.WithEnv(envs...)}
+		if flagAccrualPort != "" {
+			opts = append(opts, fork.WithEnv("RUN_ADDRESS="+flagAccrualHost+":"+flagAccrualPort))
+		} else {
+			opts = append(opts,
+				fork.WithAutoPort("accrual"),
+				fork.WithEnv("RUN_ADDRESS="+flagAccrualHost+":{{.Ports.accrual}}"),
+			)
+		}
+		p := fork.NewBackgroundProcess(context.Background(), flagAccrualBinaryPath, opts...)
+
+		port := flagAccrualPort
+		if port == "" {
+			port = strconv.Itoa(p.Port("accrual"))
+		}
+		suite.accrualServerAddress = "http://" + flagAccrualHost + ":" + port
 
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
@@ -54,7 +67,6 @@ func (suite *AccrualSuite) SetupSuite() {
 
 		suite.accrualProcess = p
 
-		port := flagAccrualPort
 		err = p.WaitPort(ctx, "tcp", port)
 		if err != nil {
 			suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
@@ -237,36 +249,39 @@ func (suite *AccrualSuite) TestRegisterOrder() {
 	httpc := resty.New().
 		SetHostURL(suite.accrualServerAddress)
 
-	successOrderNumber, err := generateOrderNumber(suite.T())
-	suite.Require().NoErrorf(err, "не удалось сгенерировать номер заказа")
+	successOrderNumber := random.LuhnNumber(6, 16)
 
 	suite.Run("bad_order_number", func() {
-		o := []byte(`
-			{
-				"order": "12345678901",
-				"goods": [
+		for _, bad := range random.InvalidLuhnNumbers(6, 16) {
+			suite.Run(bad.Kind, func() {
+				o := []byte(`
 					{
-						"description": "Стиральная машинка LG",
-						"price": 47399.99
+						"order": "` + bad.Number + `",
+						"goods": [
+							{
+								"description": "Стиральная машинка LG",
+								"price": 47399.99
+							}
+						]
 					}
-				]
-			}
-		`)
+				`)
 
-		req := httpc.R().
-			SetHeader("Content-Type", "application/json").
-			SetBody(o)
+				req := httpc.R().
+					SetHeader("Content-Type", "application/json").
+					SetBody(o)
 
-		resp, err := req.Post("/api/orders")
+				resp, err := req.Post("/api/orders")
 
-		noRespErr := suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос на регистрацию механики")
-		validStatus := suite.Assert().Equalf(http.StatusBadRequest, resp.StatusCode(),
-			"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL,
-		)
+				noRespErr := suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос на регистрацию заказа")
+				validStatus := suite.Assert().Equalf(http.StatusBadRequest, resp.StatusCode(),
+					"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s' для неверного номера заказа %q", req.Method, req.URL, bad.Number,
+				)
 
-		if !noRespErr || !validStatus {
-			dump := dumpRequest(suite.T(), req.RawRequest, bytes.NewReader(o))
-			suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				if !noRespErr || !validStatus {
+					dump := dumpRequest(suite.T(), req.RawRequest, bytes.NewReader(o))
+					suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				}
+			})
 		}
 	})
 
@@ -339,8 +354,7 @@ func (suite *AccrualSuite) TestEndToEnd() {
 	httpc := resty.New().
 		SetHostURL(suite.accrualServerAddress)
 
-	orderNumber, err := generateOrderNumber(suite.T())
-	suite.Require().NoError(err, "не удалось сгенерировать номер заказа")
+	orderNumber := random.LuhnNumber(6, 16)
 
 	suite.Run("register_mechanics", func() {
 		mechanics := [][]byte{
@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"context"
-	mathrand "math/rand"
 	"net/http"
 	"net/http/cookiejar"
 	"strconv"
@@ -26,8 +25,7 @@ func (suite *GophermartSuite) TestEndToEnd() {
 	trademark := random.ASCIIString(10, 20)
 	expectedAccrual := float32(729.98)
 
-	orderNum, err := generateOrderNumber(suite.T())
-	suite.Require().NoError(err, "Не удалось сгенерировать номер заказа")
+	orderNum := random.LuhnNumber(6, 16)
 
 	suite.Run("register_accrual_mechanic", func() {
 		m := []byte(`
@@ -237,13 +235,39 @@ func (suite *GophermartSuite) TestEndToEnd() {
 		}
 	})
 
-	withdrawOrder, err := generateOrderNumber(suite.T())
-	suite.Require().NoError(err, "Не удалось сгенерировать номер заказа")
+	withdrawOrder := random.LuhnNumber(6, 16)
 
-	randSrc := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+	randSrc := random.NewRand(suite.T().Name())
 	min, max := 100, 700
 	withdrawSum := float32(randSrc.Intn(max-min)+min) + randSrc.Float32()
 
+	suite.Run("withdraw_bad_order", func() {
+		for _, bad := range random.InvalidLuhnNumbers(6, 16) {
+			suite.Run(bad.Kind, func() {
+				body := []byte(`{
+					"order": "` + bad.Number + `",
+					"sum": ` + strconv.FormatFloat(float64(withdrawSum), 'f', 2, 32) + `
+				}`)
+
+				req := httpc.R().
+					SetHeader("Content-Type", "application/json").
+					SetBody(body)
+
+				resp, err := req.Post("/api/user/balance/withdraw")
+
+				noRespErr := suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос на списание средств с баланса пользователя в системе лояльности")
+				validStatus := suite.Assert().Equalf(http.StatusUnprocessableEntity, resp.StatusCode(),
+					"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s' для неверного номера заказа %q", req.Method, req.URL, bad.Number,
+				)
+
+				if !noRespErr || !validStatus {
+					dump := dumpRequest(suite.T(), req.RawRequest, bytes.NewReader(body))
+					suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				}
+			})
+		}
+	})
+
 	suite.Run("withdraw_balance", func() {
 		body := []byte(`{
 			"order": "` + withdrawOrder + `",
@@ -22,12 +22,10 @@ func (suite *GophermartSuite) TestUserOrders() {
 	login := random.ASCIIString(7, 14)
 	password := random.ASCIIString(16, 32)
 
-	orderNum, err := generateOrderNumber(suite.T())
-	suite.Require().NoError(err, "Не удалось сгенерировать номер заказа")
+	orderNum := random.LuhnNumber(6, 16)
 
 	suite.Run("unauthorized_order_upload", func() {
-		number, err := generateOrderNumber(suite.T())
-		suite.Require().NoError(err, "Не удалось сгенерировать номер заказа")
+		number := random.LuhnNumber(6, 16)
 
 		body := []byte(number)
 
@@ -94,22 +92,32 @@ func (suite *GophermartSuite) TestUserOrders() {
 	})
 
 	suite.Run("bad_order_upload", func() {
-		body := []byte(`12345678902`)
-
-		req := httpc.R().
-			SetHeader("Content-Type", "text/plain").
-			SetBody(body)
-
-		resp, err := req.Post("/api/user/orders")
-
-		noRespErr := suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос на регистрацию механики")
-		validStatus := suite.Assert().Equalf(http.StatusUnprocessableEntity, resp.StatusCode(),
-			"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL,
-		)
-
-		if !noRespErr || !validStatus {
-			dump := dumpRequest(suite.T(), req.RawRequest, bytes.NewReader(body))
-			suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+		for _, bad := range random.InvalidLuhnNumbers(6, 16) {
+			suite.Run(bad.Kind, func() {
+				body := []byte(bad.Number)
+
+				req := httpc.R().
+					SetHeader("Content-Type", "text/plain").
+					SetBody(body)
+
+				resp, err := req.Post("/api/user/orders")
+
+				// пустое тело запроса допустимо считать как неверным форматом запроса, так и неверным номером заказа
+				expected := []int{http.StatusUnprocessableEntity}
+				if bad.Number == "" {
+					expected = append(expected, http.StatusBadRequest)
+				}
+
+				noRespErr := suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос на загрузку номера заказа")
+				validStatus := suite.Assert().Containsf(expected, resp.StatusCode(),
+					"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s' для неверного номера заказа %q", req.Method, req.URL, bad.Number,
+				)
+
+				if !noRespErr || !validStatus {
+					dump := dumpRequest(suite.T(), req.RawRequest, bytes.NewReader(body))
+					suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				}
+			})
 		}
 	})
 
@@ -2,9 +2,8 @@ package main
 
 import (
 	"context"
-	"errors"
 	"os"
-	"syscall"
+	"strconv"
 	"time"
 
 	"github.com/stretchr/testify/suite"
@@ -17,10 +16,9 @@ type GophermartSuite struct {
 	suite.Suite
 
 	gophermartServerAddress string
-	gophermartProcess       *fork.BackgroundProcess
+	accrualServerAddress    string
 
-	accrualServerAddress string
-	accrualProcess       *fork.BackgroundProcess
+	processes *fork.Supervisor
 }
 
 // SetupSuite bootstraps suite dependencies
@@ -29,115 +27,114 @@ func (suite *GophermartSuite) SetupSuite() {
 	suite.Require().NotEmpty(flagGophermartBinaryPath, "-gophermart-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagGophermartDatabaseURI, "-gophermart-database-uri non-empty flag required")
 	suite.Require().NotEmpty(flagGophermartHost, "-gophermart-host non-empty flag required")
-	suite.Require().NotEmpty(flagGophermartPort, "-gophermart-port non-empty flag required")
 
 	suite.Require().NotEmpty(flagAccrualBinaryPath, "-accrual-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagAccrualDatabaseURI, "-accrual-database-uri non-empty flag required")
 	suite.Require().NotEmpty(flagAccrualHost, "-accrual-host non-empty flag required")
-	suite.Require().NotEmpty(flagAccrualPort, "-accrual-port non-empty flag required")
-
-	// start accrual server
-	{
-		suite.accrualServerAddress = "http://" + flagAccrualHost + ":" + flagAccrualPort
-
-		envs := append(os.Environ(),
-			"RUN_ADDRESS="+flagAccrualHost+":"+flagAccrualPort,
-			"DATABASE_URI="+flagAccrualDatabaseURI,
-		)
-		p := fork.NewBackgroundProcess(context.Background(), flagAccrualBinaryPath,
-			fork.WithEnv(envs...),
-		)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-
-		err := p.Start(ctx)
-		if err != nil {
-			suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
-			return
-		}
-
-		suite.accrualProcess = p
 
-		port := flagAccrualPort
-		err = p.WaitPort(ctx, "tcp", port)
-		if err != nil {
-			suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
+	accrualOpts, accrualReady := serverAddressSpec("accrual", flagAccrualHost, flagAccrualPort)
+	gophermartOpts, gophermartReady := serverAddressSpec("gophermart", flagGophermartHost, flagGophermartPort)
 
-			return
-		}
+	// if accrual port is leased, gophermart receives it through dependency template
+	accrualAddress := "http://" + flagAccrualHost + ":" + flagAccrualPort
+	if flagAccrualPort == "" {
+		accrualAddress = "http://" + flagAccrualHost + ":{{.Deps.accrual.Ports.accrual}}"
 	}
 
-	// start gophermart server
-	{
-		suite.gophermartServerAddress = "http://" + flagGophermartHost + ":" + flagGophermartPort
-
-		envs := append(os.Environ(),
-			"RUN_ADDRESS="+flagGophermartHost+":"+flagGophermartPort,
-			"DATABASE_URI="+flagGophermartDatabaseURI,
-			"ACCRUAL_SYSTEM_ADDRESS="+suite.accrualServerAddress,
-		)
-		p := fork.NewBackgroundProcess(context.Background(), flagGophermartBinaryPath,
-			fork.WithEnv(envs...),
-		)
-
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-
-		err := p.Start(ctx)
-		if err != nil {
-			suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
-			return
-		}
-
-		suite.gophermartProcess = p
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "accrual",
+			Command: flagAccrualBinaryPath,
+			Opts: append([]fork.ProcessOpt{
+				fork.WithEnv(os.Environ()...),
+				fork.WithEnv("DATABASE_URI=" + flagAccrualDatabaseURI),
+			}, accrualOpts...),
+			Ready: accrualReady,
+		},
+		fork.ProcessSpec{
+			Name:    "gophermart",
+			Command: flagGophermartBinaryPath,
+			Opts: append([]fork.ProcessOpt{
+				fork.WithEnv(os.Environ()...),
+				fork.WithEnv(
+					"DATABASE_URI="+flagGophermartDatabaseURI,
+					"ACCRUAL_SYSTEM_ADDRESS="+accrualAddress,
+				),
+			}, gophermartOpts...),
+			DependsOn: []string{"accrual"},
+			Ready:     gophermartReady,
+		},
+	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
+
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-		port := flagGophermartPort
-		err = p.WaitPort(ctx, "tcp", port)
-		if err != nil {
-			suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-			return
-		}
+	err = processes.Start(ctx)
+	if err != nil {
+		suite.T().Errorf("Не удалось запустить процессы accrual и gophermart: %s", err)
+		return
 	}
+
+	suite.accrualServerAddress = serverAddress(processes.Process("accrual"), "accrual", flagAccrualHost, flagAccrualPort)
+	suite.gophermartServerAddress = serverAddress(processes.Process("gophermart"), "gophermart", flagGophermartHost, flagGophermartPort)
 }
 
-// TearDownSuite teardowns suite dependencies
-func (suite *GophermartSuite) TearDownSuite() {
-	suite.T().Logf("останавливаем процесс gophermart")
-	suite.stopBinaryProcess(suite.gophermartProcess)
+// serverAddressSpec returns options and readiness probes of HTTP server listening on host:port.
+// If port is empty, a free port is leased for the server under given name
+func serverAddressSpec(name, host, port string) (opts []fork.ProcessOpt, ready []fork.ReadinessProbe) {
+	if port != "" {
+		opts = append(opts, fork.WithEnv("RUN_ADDRESS="+host+":"+port))
+		ready = append(ready, fork.PortProbe("tcp", port), fork.HTTPProbe("http://"+host+":"+port))
+		return opts, ready
+	}
 
-	suite.T().Log("останавливаем процесс accrual")
-	suite.stopBinaryProcess(suite.accrualProcess)
+	opts = append(opts,
+		fork.WithAutoPort(name),
+		fork.WithEnv("RUN_ADDRESS="+host+":{{.Ports."+name+"}}"),
+	)
+	ready = append(ready,
+		fork.AutoPortProbe("tcp", name),
+		func(ctx context.Context, p *fork.BackgroundProcess) error {
+			return p.WaitHTTP(ctx, serverAddress(p, name, host, port))
+		},
+	)
+	return opts, ready
 }
 
-func (suite *GophermartSuite) stopBinaryProcess(p *fork.BackgroundProcess) {
-	if p == nil {
-		return
+// serverAddress returns base URL of HTTP server started with serverAddressSpec
+func serverAddress(p *fork.BackgroundProcess, name, host, port string) string {
+	if port == "" {
+		port = strconv.Itoa(p.Port(name))
 	}
+	return "http://" + host + ":" + port
+}
 
-	exitCode, err := p.Stop(syscall.SIGINT, syscall.SIGKILL)
-	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+// TearDownSuite teardowns suite dependencies
+func (suite *GophermartSuite) TearDownSuite() {
+	if suite.processes == nil {
 		return
 	}
 
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
+	suite.T().Log("останавливаем процессы gophermart и accrual")
 
-	// try to read stdout/stderr
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	out := p.Stderr(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	results, err := suite.processes.Stop(ctx)
+	if err != nil {
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
 	}
-	out = p.Stdout(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	for name, res := range results {
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
+		suite.T().Logf("Потребление ресурсов процессом %s: %s", name, res.Stats)
+	}
+
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
 	}
 }
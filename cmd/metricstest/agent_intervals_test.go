@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
+)
+
+// agentIntervalsBatches количество пакетов отправки метрик, которые ожидаются от агента в каждом случае
+const agentIntervalsBatches = 4
+
+// AgentIntervalsSuite проверяет, что агент соблюдает интервалы опроса и отправки метрик,
+// заданные флагами -p и -r и переменными окружения POLL_INTERVAL и REPORT_INTERVAL
+type AgentIntervalsSuite struct {
+	suite.Suite
+}
+
+// agentIntervalsCase описывает запуск агента с определенными интервалами
+type agentIntervalsCase struct {
+	name string
+	// флаги командной строки агента, кроме адреса сервера
+	args []string
+	// переменные окружения агента, кроме адреса сервера
+	envs []string
+	// интервалы, которые должен использовать агент
+	poll   time.Duration
+	report time.Duration
+}
+
+func (suite *AgentIntervalsSuite) SetupSuite() {
+	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
+	suite.Require().Positive(flagTimingTolerance, "-timing-tolerance positive flag required")
+}
+
+// TestIntervals проверяет:
+// - интервалы между пакетами отправки метрик соответствуют интервалу отправки
+// - значение PollCount за один пакет растет на отношение интервала отправки к интервалу опроса
+// - значения из переменных окружения имеют приоритет над флагами командной строки
+func (suite *AgentIntervalsSuite) TestIntervals() {
+	cases := []agentIntervalsCase{
+		{
+			name:   "flags_p1_r2",
+			args:   []string{"-p=1", "-r=2"},
+			poll:   time.Second,
+			report: 2 * time.Second,
+		},
+		{
+			name:   "flags_p1_r3",
+			args:   []string{"-p=1", "-r=3"},
+			poll:   time.Second,
+			report: 3 * time.Second,
+		},
+		{
+			name:   "env_over_flags",
+			args:   []string{"-p=2", "-r=6"},
+			envs:   []string{"POLL_INTERVAL=1", "REPORT_INTERVAL=2"},
+			poll:   time.Second,
+			report: 2 * time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		suite.Run(tc.name, func() {
+			suite.runCase(tc)
+		})
+	}
+}
+
+// runCase запускает агента с поддельным сервером и проверяет записанные запросы
+func (suite *AgentIntervalsSuite) runCase(tc agentIntervalsCase) {
+	srv, err := recorder.NewFake("")
+	suite.Require().NoError(err, "Не удалось запустить поддельный сервер для записи запросов агента")
+	defer srv.Close()
+
+	// адрес передается и флагом, и переменной окружения, чтобы не зависеть от порядка их применения
+	args := append([]string{"-a=" + srv.Addr()}, tc.args...)
+	envs := append(os.Environ(), "ADDRESS="+srv.Addr())
+	envs = append(envs, tc.envs...)
+
+	agent := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
+		fork.WithEnv(envs...),
+		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err = agent.Start(ctx)
+	suite.Require().NoErrorf(err, "Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v, флаги командной строки: %+v", agent, err, tc.envs, args)
+	defer suite.agentShutdown(agent)
+
+	// ожидаем пакеты с запасом на задержку запуска агента
+	timeout := time.Duration(agentIntervalsBatches+2)*tc.report*2 + 10*time.Second
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), timeout)
+	defer waitCancel()
+
+	updates, err := srv.WaitUpdates(waitCtx, "PollCount", agentIntervalsBatches)
+	suite.Require().NoErrorf(err, "Не удалось дождаться %d отправок метрики PollCount за %s, получено %d",
+		agentIntervalsBatches, timeout, len(updates))
+
+	suite.Run("report interval", func() {
+		for _, m := range recorder.IntervalMismatches(srv.UpdateIntervals("PollCount"), tc.report, flagTimingTolerance) {
+			suite.Assert().Failf("Интервал между пакетами отправки метрик не соответствует интервалу отправки", "%s", m)
+		}
+	})
+
+	suite.Run("poll count", func() {
+		ratio := float64(tc.report) / float64(tc.poll)
+		// опрос и отправка не синхронизированы, поэтому допускаем дополнительную разницу в один опрос
+		allowed := ratio*flagTimingTolerance + 1
+
+		var prev int64
+		for i, u := range updates {
+			if !suite.Assert().NotNilf(u.Delta, "Агент отправил PollCount без значения") {
+				return
+			}
+			// первый пакет может содержать опросы, выполненные до начала отправки
+			if i > 0 {
+				suite.Assert().Truef(pollCountGrowthOK(prev, *u.Delta, ratio, allowed),
+					"Значение PollCount в пакете %d равно %d после %d в предыдущем, ожидался рост на %.1f ± %.1f",
+					i+1, *u.Delta, prev, ratio, allowed)
+			}
+			prev = *u.Delta
+		}
+	})
+}
+
+// pollCountGrowthOK проверяет рост PollCount между пакетами.
+// Агент может отправлять как накопленное значение счетчика, так и приращение с момента предыдущей отправки
+func pollCountGrowthOK(prev, cur int64, ratio, allowed float64) bool {
+	cumulative := math.Abs(float64(cur-prev)-ratio) <= allowed
+	delta := math.Abs(float64(cur)-ratio) <= allowed
+	return cumulative || delta
+}
+
+func (suite *AgentIntervalsSuite) agentShutdown(agent *fork.BackgroundProcess) {
+	exitCode, err := agent.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), agent)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return
+		}
+		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		return
+	}
+
+	if exitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	}
+
+	if !suite.T().Failed() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	out := agent.Stderr(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	}
+	out = agent.Stdout(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	}
+}
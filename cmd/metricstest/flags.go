@@ -2,18 +2,26 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 var (
-	flagAgentBinaryPath  string
-	flagServerBinaryPath string
-	flagTargetSourcePath string
-	flagServerHost       string
-	flagServerPort       string
-	flagServerBaseURL    string
-	flagFileStoragePath  string
-	flagDatabaseDSN      string
-	flagSHA256Key        string
+	flagAgentBinaryPath    string
+	flagServerBinaryPath   string
+	flagTargetSourcePath   string
+	flagServerHost         string
+	flagServerPort         string
+	flagServerBaseURL      string
+	flagFileStoragePath    string
+	flagDatabaseDSN        string
+	flagSHA256Key          string
+	flagTimingTolerance    float64
+	flagRaceBuild          bool   // собирать агента и сервер из исходного кода с -race и -cover
+	flagServerBuildPackage string // пакет проекта, из которого собирается сервер
+	flagAgentBuildPackage  string // пакет проекта, из которого собирается агент
+	flagCoverProfilePath   string // путь до итогового профиля интеграционного покрытия
+	flagExtremeEdgeCases   bool   // проверять предельные значения и идентификаторы метрик
 )
 
 func init() {
@@ -26,4 +34,11 @@ func init() {
 	flag.StringVar(&flagFileStoragePath, "file-storage-path", "", "path to persistent file storage")
 	flag.StringVar(&flagDatabaseDSN, "database-dsn", "", "connection string to database")
 	flag.StringVar(&flagSHA256Key, "key", "", "sha256 key for hashing")
+	flag.Float64Var(&flagTimingTolerance, "timing-tolerance", 0.25, "relative tolerance for agent poll and report interval checks")
+	flag.BoolVar(&flagRaceBuild, "race-build", false, "build target agent and server from -source-path with -race and -cover instead of using -agent-binary-path and -binary-path")
+	flag.StringVar(&flagServerBuildPackage, "server-build-package", "./cmd/server", "package of target server to build in -race-build mode")
+	flag.StringVar(&flagAgentBuildPackage, "agent-build-package", "./cmd/agent", "package of target agent to build in -race-build mode")
+	flag.StringVar(&flagCoverProfilePath, "coverprofile", "", "path to write integration coverage profile to in -race-build mode")
+	flag.BoolVar(&flagExtremeEdgeCases, "extreme-edge-cases", false, "also check float64 limits, subnormals, negative zero, long and Unicode metric IDs")
+	random.RegisterSeedFlag()
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration10ASuite struct {
@@ -33,7 +34,7 @@ func (suite *Iteration10ASuite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -62,6 +63,7 @@ func (suite *Iteration10ASuite) serverUp(ctx context.Context, envs, args []strin
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -87,6 +89,7 @@ func (suite *Iteration10ASuite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
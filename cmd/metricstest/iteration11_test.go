@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration11Suite struct {
@@ -40,7 +41,7 @@ func (suite *Iteration11Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -88,6 +89,7 @@ func (suite *Iteration11Suite) serverUp(ctx context.Context, envs, args []string
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -103,6 +105,39 @@ func (suite *Iteration11Suite) serverUp(ctx context.Context, envs, args []string
 	}
 }
 
+// serverRestart перезапускает процесс сервера с прежними аргументами и переменными окружения
+func (suite *Iteration11Suite) serverRestart() {
+	suite.Require().NotNil(suite.serverProcess, "Процесс сервера не был запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err := suite.serverProcess.Restart(ctx)
+	if err != nil {
+		suite.T().Errorf("Не удалось перезапустить процесс командой %q: %s", suite.serverProcess, err)
+		return
+	}
+
+	// выводим логи предыдущего запуска
+	incarnations := suite.serverProcess.Incarnations()
+	prev := incarnations[len(incarnations)-1]
+	if prev.ExitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", prev.ExitCode)
+	}
+	if len(prev.Stderr) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(prev.Stderr))
+	}
+	if len(prev.Stdout) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(prev.Stdout))
+	}
+
+	err = suite.serverProcess.WaitPort(ctx, "tcp", suite.serverPort)
+	if err != nil {
+		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", suite.serverPort, err)
+		return
+	}
+}
+
 func (suite *Iteration11Suite) TearDownSuite() {
 	suite.serverShutdown()
 }
@@ -113,6 +148,7 @@ func (suite *Iteration11Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -141,17 +177,19 @@ func (suite *Iteration11Suite) serverShutdown() {
 // TestInspectDatabase attempts to:
 // - generate and send random counter
 // - inspect database to find original counter record
+// - restart server and read counter restored from database
 func (suite *Iteration11Suite) TestInspectDatabase() {
 	id := "PopulateCounter" + strconv.Itoa(suite.rnd.Intn(256*256*256))
 
 	httpc := resty.New().
 		SetHostURL(suite.serverAddress)
 
+	value := suite.rnd.Int63n(1000) + 1
+
 	suite.Run("populate counter", func() {
 		req := httpc.R().
 			SetHeader("Content-Type", "application/json")
 
-		var value int64
 		resp, err := req.
 			SetBody(
 				&Metrics{
@@ -201,6 +239,40 @@ func (suite *Iteration11Suite) TestInspectDatabase() {
 		suite.Require().Truef(found,
 			"Не удалось обнаружить запись с оригинальной метрикой счетчика ни в одной таблице базы данных. Оригинальный ID метрики счетчика: %s", id)
 	})
+
+	suite.Run("restart server", func() {
+		suite.serverRestart()
+	})
+
+	suite.Run("restored counter", func() {
+		req := httpc.R().
+			SetHeader("Content-Type", "application/json")
+
+		var result Metrics
+		resp, err := req.
+			SetBody(&Metrics{
+				ID:    id,
+				MType: "counter",
+			}).
+			SetResult(&result).
+			Post("value/")
+
+		dumpErr := suite.Assert().NoError(err,
+			"Ошибка при попытке сделать запрос с получением значения counter")
+		dumpErr = dumpErr && suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
+			"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", req.Method, req.URL)
+		dumpErr = dumpErr && suite.Assert().NotNilf(result.Delta,
+			"Получено не инициализированное значение Delta '%q %s'", req.Method, req.URL)
+		dumpErr = dumpErr && suite.Assert().Equalf(value, *result.Delta,
+			"Значение counter после перезапуска сервера (%d) не соответствует сохраненному до перезапуска (%d)", *result.Delta, value)
+
+		if !dumpErr {
+			dump := dumpRequest(req.RawRequest, true)
+			suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			dump = dumpResponse(resp.RawResponse, true)
+			suite.T().Logf("Оригинальный ответ:\n\n%s", dump)
+		}
+	})
 }
 
 func (suite *Iteration11Suite) fetchTables() ([]string, error) {
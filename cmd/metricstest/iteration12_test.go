@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration12Suite struct {
@@ -39,7 +40,7 @@ func (suite *Iteration12Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -89,6 +90,7 @@ func (suite *Iteration12Suite) serverUp(ctx context.Context, envs, args []string
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -114,6 +116,7 @@ func (suite *Iteration12Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
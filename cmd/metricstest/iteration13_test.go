@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"math"
 	"math/rand"
 	"net/http"
 	"os"
@@ -13,7 +14,9 @@ import (
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/Yandex-Practicum/go-autotests/internal/faultproxy"
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration13Suite struct {
@@ -35,7 +38,7 @@ func (suite *Iteration13Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -63,6 +66,7 @@ func (suite *Iteration13Suite) serverUp(ctx context.Context, envs, args []string
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -82,6 +86,7 @@ func (suite *Iteration13Suite) agentUp(ctx context.Context, envs, args []string,
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.agentProcess.Start(ctx)
@@ -108,6 +113,7 @@ func (suite *Iteration13Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -139,6 +145,7 @@ func (suite *Iteration13Suite) agentShutdown() {
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -443,3 +450,71 @@ cont:
 		})
 	}
 }
+
+// TestAgentRetry проверяет, что агент повторяет отправку метрик после сетевой ошибки,
+// не дожидаясь следующего интервала отправки
+func (suite *Iteration13Suite) TestAgentRetry() {
+	const (
+		reportInterval = 10 * time.Second
+		// агент должен повторить запрос через 1s, 3s и 5s после ошибки
+		retryWait = 6 * time.Second
+	)
+
+	proxy, err := faultproxy.NewHTTP("", "localhost:"+flagServerPort)
+	suite.Require().NoError(err, "Не удалось запустить прокси для внесения сбоев")
+	defer proxy.Close()
+
+	envs := append(os.Environ(), []string{
+		"ADDRESS=" + proxy.Addr(),
+		"REPORT_INTERVAL=10",
+		"POLL_INTERVAL=1",
+	}...)
+	args := []string{
+		"-a=" + proxy.Addr(),
+		"-r=10",
+		"-p=1",
+	}
+	agent := fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
+		fork.WithEnv(envs...),
+		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err = agent.Start(ctx)
+	suite.Require().NoErrorf(err, "Невозможно запустить процесс командой %q. Переменные окружения: %+v, флаги командной строки: %+v", agent, envs, args)
+	defer func() {
+		_, _ = agent.Stop(syscall.SIGINT, syscall.SIGKILL)
+		fork.CheckDataRaces(suite.T(), agent)
+		if !suite.T().Failed() {
+			return
+		}
+		outCtx, outCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer outCancel()
+		if out := agent.Stderr(outCtx); len(out) > 0 {
+			suite.T().Logf("Получен STDERR лог агента:\n\n%s", string(out))
+		}
+		if out := agent.Stdout(outCtx); len(out) > 0 {
+			suite.T().Logf("Получен STDOUT лог агента:\n\n%s", string(out))
+		}
+	}()
+
+	// дожидаемся первой отправки метрик, чтобы сбой пришелся на одну из следующих
+	suite.Require().Eventually(func() bool { return proxy.Requests() > 0 }, 2*reportInterval, 10*time.Millisecond,
+		"Агент не отправил метрики через прокси")
+
+	// разрываем соединения, пока агент не попытается отправить метрики
+	proxy.ResetNext(math.MaxInt)
+	suite.Require().Eventually(func() bool { return proxy.Injected() > 0 }, 2*reportInterval, 10*time.Millisecond,
+		"Агент не пытался отправить метрики после включения сбоя")
+
+	// даем агенту без повторов отправить остальные метрики пакета и восстанавливаем связь
+	time.Sleep(300 * time.Millisecond)
+	proxy.Clear()
+	requests := proxy.Requests()
+
+	suite.Assert().Eventuallyf(func() bool { return proxy.Requests() > requests }, retryWait, 10*time.Millisecond,
+		"Агент не повторил отправку метрик в течение %s после сетевой ошибки, хотя интервал отправки равен %s", retryWait, reportInterval)
+}
@@ -2,29 +2,35 @@ package main
 
 import (
 	"context"
-	// "crypto/hmac"
-	// "crypto/sha256"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"math/rand"
 	"net/http"
 	"os"
 	"strconv"
-	"syscall"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
 )
 
+// hashHeader заголовок, в котором агент и сервер передают подпись тела запроса или ответа
+const hashHeader = "HashSHA256"
+
 type Iteration14Suite struct {
 	suite.Suite
 
 	serverAddress string
 	serverPort    string
-	serverProcess *fork.BackgroundProcess
-	agentProcess  *fork.BackgroundProcess
+	processes     *fork.Supervisor
+	agentRecorder *recorder.Recorder
 
 	rnd *rand.Rand
 
@@ -39,7 +45,7 @@ func (suite *Iteration14Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagSHA256Key, "-key non-empty flag required")
 	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -59,114 +65,67 @@ func (suite *Iteration14Suite) SetupSuite() {
 		"-k=invalidkey",
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-	defer cancel()
-	suite.agentUp(ctx, envs, agentArgs, flagServerPort)
-	suite.serverUp(ctx, envs, serverArgs, flagServerPort)
-}
-
-func (suite *Iteration14Suite) serverUp(ctx context.Context, envs, args []string, port string) {
-	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
-		fork.WithEnv(envs...),
-		fork.WithArgs(args...),
-	)
-
-	err := suite.serverProcess.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v, флаги командной строки: %+v", suite.serverProcess, err, envs, args)
-		return
-	}
-
-	err = suite.serverProcess.WaitPort(ctx, "tcp", port)
-	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
-		return
-	}
-}
-
-func (suite *Iteration14Suite) agentUp(ctx context.Context, envs, args []string, port string) {
-	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
-		fork.WithEnv(envs...),
-		fork.WithArgs(args...),
+	// агент отправляет метрики через прокси, записывающий все запросы к серверу
+	rec, err := recorder.New("", "localhost:"+flagServerPort)
+	suite.Require().NoError(err, "Не удалось запустить прокси для записи запросов агента")
+	suite.agentRecorder = rec
+
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "server",
+			Command: flagServerBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(envs...), fork.WithArgs(serverArgs...), instrumented.WithCoverDir()},
+			Ready:   []fork.ReadinessProbe{fork.PortProbe("tcp", flagServerPort)},
+		},
+		fork.ProcessSpec{
+			Name:      "agent",
+			Command:   flagAgentBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(append(envs, "ADDRESS="+rec.Addr())...), fork.WithArgs(agentArgs...), instrumented.WithCoverDir()},
+			DependsOn: []string{"server"},
+			Ready: []fork.ReadinessProbe{func(ctx context.Context, _ *fork.BackgroundProcess) error {
+				// ожидаем первого записанного запроса агента
+				return rec.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
+			}},
+		},
 	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
 
-	err := suite.agentProcess.Start(ctx)
-	if err != nil {
-		suite.T().Errorf("Невозможно запустить процесс командой %q: %s. Переменные окружения: %+v, флаги командной строки: %+v", suite.agentProcess, err, envs, args)
-		return
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
 
-	err = suite.agentProcess.ListenPort(ctx, "tcp", port)
+	err = processes.Start(ctx)
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
+		suite.T().Errorf("Не удалось запустить процессы агента и сервера: %s. Переменные окружения: %+v", err, envs)
 		return
 	}
 }
 
 func (suite *Iteration14Suite) TearDownSuite() {
-	suite.agentShutdown()
-	suite.serverShutdown()
-}
-
-func (suite *Iteration14Suite) serverShutdown() {
-	if suite.serverProcess == nil {
-		return
+	if suite.agentRecorder != nil {
+		defer suite.agentRecorder.Close()
 	}
-
-	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
-	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+	if suite.processes == nil {
 		return
 	}
 
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	out := suite.serverProcess.Stderr(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
-	}
-	out = suite.serverProcess.Stdout(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
-	}
-}
-
-func (suite *Iteration14Suite) agentShutdown() {
-	if suite.agentProcess == nil {
-		return
-	}
-
-	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	results, err := suite.processes.Stop(ctx)
 	if err != nil {
-		if errors.Is(err, os.ErrProcessDone) {
-			return
-		}
-		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
-		return
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
 	}
-
-	if exitCode > 0 {
-		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	for name, res := range results {
+		fork.CheckDataRaces(suite.T(), suite.processes.Process(name))
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-
-	out := suite.agentProcess.Stderr(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
-	}
-	out = suite.agentProcess.Stdout(ctx)
-	if len(out) > 0 {
-		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
 	}
 }
 
@@ -182,11 +141,10 @@ func (suite *Iteration14Suite) TestCounterGzipHandlers() {
 			SetHeader("Content-Type", "application/json")
 
 		var result Metrics
-		resp, err := req.
-			SetBody(&Metrics{
-				ID:    id,
-				MType: "counter",
-			}).
+		resp, err := suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "counter",
+		}).
 			SetResult(&result).
 			Post("value/")
 
@@ -209,35 +167,32 @@ func (suite *Iteration14Suite) TestCounterGzipHandlers() {
 			return
 		}
 
-		resp, err = req.SetBody(
-			&Metrics{
-				ID:    id,
-				MType: "counter",
-				Delta: &value1,
-			}).Post("update/")
+		resp, err = suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "counter",
+			Delta: &value1,
+		}).Post("update/")
 
 		dumpErr = dumpErr && suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос с обновлением counter")
 		dumpErr = dumpErr && suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
 			"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", req.Method, req.URL)
 
-		resp, err = req.SetBody(
-			&Metrics{
-				ID:    id,
-				MType: "counter",
-				Delta: &value2,
-			}).Post("update/")
+		resp, err = suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "counter",
+			Delta: &value2,
+		}).Post("update/")
 
 		dumpErr = dumpErr && suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос с обновлением counter")
 		dumpErr = dumpErr && suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
 			"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", req.Method, req.URL)
 
-		resp, err = req.
-			SetBody(&Metrics{
-				ID:    id,
-				MType: "counter",
-			}).
+		resp, err = suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "counter",
+		}).
 			SetResult(&result).
 			Post("value/")
 
@@ -253,7 +208,8 @@ func (suite *Iteration14Suite) TestCounterGzipHandlers() {
 			"Несоответствие отправленного значения counter (r:%d+w:%d+w:%d) полученному от сервера (nil), '%q %s'", value0, value1, value2, req.Method, req.URL)
 		dumpErr = dumpErr && suite.Assert().Equalf(value0+value1+value2, *result.Delta,
 			"Несоответствие отправленного значения counter (r:%d+w:%d+w:%d) полученному от сервера (%d), '%q %s'", value0, value1, value2, *result.Delta, req.Method, req.URL)
-		// dumpErr = dumpErr && suite.Equal(suite.Hash(&result), result.Hash, "Хеш-сумма не соответствует расчетной")
+		dumpErr = dumpErr && suite.Assert().Equalf(suite.Hash(resp.Body()), resp.Header().Get(hashHeader),
+			"Подпись ответа в заголовке %s не соответствует расчетной", hashHeader)
 
 		if !dumpErr {
 			dump := dumpRequest(req.RawRequest, true)
@@ -278,16 +234,14 @@ func (suite *Iteration14Suite) TestGaugeGzipHandlers() {
 	suite.Run("update", func() {
 		value := suite.rnd.Float64() * 1e6
 		req := httpc.R().
-			SetHeader("Hash", "none").
 			SetHeader("Accept-Encoding", "gzip").
 			SetHeader("Content-Type", "application/json")
 
-		resp, err := req.SetBody(
-			&Metrics{
-				ID:    id,
-				MType: "gauge",
-				Value: &value,
-			}).Post("update/")
+		resp, err := suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "gauge",
+			Value: &value,
+		}).Post("update/")
 
 		dumpErr := suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос с обновлением gauge")
@@ -295,11 +249,10 @@ func (suite *Iteration14Suite) TestGaugeGzipHandlers() {
 			"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", req.Method, req.URL)
 
 		var result Metrics
-		resp, err = req.
-			SetBody(&Metrics{
-				ID:    id,
-				MType: "gauge",
-			}).
+		resp, err = suite.SetHBody(req, &Metrics{
+			ID:    id,
+			MType: "gauge",
+		}).
 			SetResult(&result).
 			Post("value/")
 
@@ -315,7 +268,8 @@ func (suite *Iteration14Suite) TestGaugeGzipHandlers() {
 			"Несоответствие отправленного значения gauge (%f) полученному от сервера (nil), '%q %s'", value, req.Method, req.URL)
 		dumpErr = dumpErr && suite.Assert().Equalf(value, *result.Value,
 			"Несоответствие отправленного значения gauge (%f) полученному от сервера (%f), '%q %s'", value, *result.Value, req.Method, req.URL)
-		// dumpErr = dumpErr && suite.Equal(suite.Hash(&result), result.Hash, "Хеш-сумма не соответствует расчетной")
+		dumpErr = dumpErr && suite.Assert().Equalf(suite.Hash(resp.Body()), resp.Header().Get(hashHeader),
+			"Подпись ответа в заголовке %s не соответствует расчетной", hashHeader)
 
 		if !dumpErr {
 			dump := dumpRequest(req.RawRequest, true)
@@ -392,11 +346,10 @@ cont:
 			time.Sleep(100 * time.Millisecond)
 
 			var result Metrics
-			resp, err = req.
-				SetBody(&Metrics{
-					ID:    tt.name,
-					MType: tt.method,
-				}).
+			resp, err = suite.SetHBody(req, &Metrics{
+				ID:    tt.name,
+				MType: tt.method,
+			}).
 				SetResult(&result).
 				Post("/value/")
 
@@ -421,7 +374,8 @@ cont:
 				"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", req.Method, req.URL)
 			dumpErr = dumpErr && suite.Assert().True(result.MType == "gauge" || result.MType == "counter",
 				"Получен ответ с неизвестным значением типа: %q, '%q %s'", result.MType, req.Method, req.URL)
-			// dumpErr = dumpErr && suite.Equal(suite.Hash(&result), result.Hash, "Хеш-сумма не соответствует расчетной")
+			dumpErr = dumpErr && suite.Assert().Equalf(suite.Hash(resp.Body()), resp.Header().Get(hashHeader),
+				"Подпись ответа в заголовке %s не соответствует расчетной", hashHeader)
 
 			if !dumpErr {
 				dump := dumpRequest(req.RawRequest, true)
@@ -459,21 +413,104 @@ cont:
 	}
 }
 
-// func (suite *Iteration14Suite) SetHBody(r *resty.Request, m *Metrics) *resty.Request {
-// 	hash := suite.Hash(m)
-// 	m.Hash = hash
-// 	return r.SetBody(m)
-// }
-
-// func (suite *Iteration14Suite) Hash(m *Metrics) string {
-// 	var data string
-// 	switch m.MType {
-// 	case "counter":
-// 		data = fmt.Sprintf("%s:%s:%d", m.ID, m.MType, *m.Delta)
-// 	case "gauge":
-// 		data = fmt.Sprintf("%s:%s:%f", m.ID, m.MType, *m.Value)
-// 	}
-// 	h := hmac.New(sha256.New, suite.key)
-// 	h.Write([]byte(data))
-// 	return fmt.Sprintf("%x", h.Sum(nil))
-// }
+// TestAgentSignature проверяет, что агент подписывает тело каждого запроса ключом -key
+func (suite *Iteration14Suite) TestAgentSignature() {
+	suite.Require().NotNil(suite.agentRecorder, "Прокси для записи запросов агента не запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err := suite.agentRecorder.WaitUpdates(ctx, "PollCount", 1)
+	suite.Require().NoError(err, "Агент не отправил значение метрики PollCount")
+
+	for _, req := range suite.agentRecorder.Requests() {
+		if len(req.RawBody) == 0 {
+			continue
+		}
+		hash := req.Header.Get(hashHeader)
+		if !suite.Assert().NotEmptyf(hash, "Агент отправил запрос без заголовка %s: %s", hashHeader, req) {
+			continue
+		}
+		// подпись может быть вычислена как от сжатого, так и от исходного тела запроса
+		suite.Assert().Truef(hash == suite.Hash(req.RawBody) || hash == suite.Hash(req.Body),
+			"Подпись в заголовке %s не соответствует телу запроса: %s", hashHeader, req)
+		suite.Assert().Equalf(http.StatusOK, req.Status, "Сервер не принял подписанный запрос агента: %s", req)
+	}
+}
+
+// TestInvalidSignature проверяет, что сервер отклоняет запросы с неверной подписью
+func (suite *Iteration14Suite) TestInvalidSignature() {
+	httpc := resty.New().SetHostURL(suite.serverAddress)
+
+	id := "InvalidHash" + random.ASCIIString(8, 16)
+	value := suite.rnd.Float64() * 1e6
+
+	tests := []struct {
+		name string
+		path string
+		body any
+	}{
+		{
+			name: "update",
+			path: "update/",
+			body: &Metrics{ID: id, MType: "gauge", Value: &value},
+		},
+		{
+			name: "updates",
+			path: "updates/",
+			body: []Metrics{{ID: id, MType: "gauge", Value: &value}},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			body, err := json.Marshal(tt.body)
+			suite.Require().NoError(err)
+
+			// подписываем тело чужим ключом
+			h := hmac.New(sha256.New, append([]byte("invalid"), suite.key...))
+			h.Write(body)
+
+			req := httpc.R().
+				SetHeader("Content-Type", "application/json").
+				SetHeader(hashHeader, hex.EncodeToString(h.Sum(nil))).
+				SetBody(body)
+			resp, err := req.Post(tt.path)
+
+			dumpErr := suite.Assert().NoError(err,
+				"Ошибка при попытке сделать запрос с неверной подписью")
+			dumpErr = dumpErr && suite.Assert().Equalf(http.StatusBadRequest, resp.StatusCode(),
+				"Сервер должен отклонять запрос с неверной подписью в заголовке %s, %q %s", hashHeader, req.Method, req.URL)
+
+			if !dumpErr {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				dump = dumpResponse(resp.RawResponse, true)
+				suite.T().Logf("Оригинальный ответ:\n\n%s", dump)
+			}
+		})
+	}
+
+	suite.Run("not stored", func() {
+		req := httpc.R().
+			SetHeader("Content-Type", "application/json")
+		resp, err := suite.SetHBody(req, &Metrics{ID: id, MType: "gauge"}).Post("value/")
+		suite.Require().NoError(err, "Ошибка при попытке сделать запрос с получением значения gauge")
+		suite.Assert().Equalf(http.StatusNotFound, resp.StatusCode(),
+			"Сервер сохранил значение метрики %s из запроса с неверной подписью", id)
+	})
+}
+
+// SetHBody устанавливает телом запроса метрику m и подписывает его в заголовке HashSHA256
+func (suite *Iteration14Suite) SetHBody(r *resty.Request, m *Metrics) *resty.Request {
+	body, err := json.Marshal(m)
+	suite.Require().NoError(err)
+	return r.SetHeader(hashHeader, suite.Hash(body)).SetBody(body)
+}
+
+// Hash возвращает подпись данных ключом -key в шестнадцатеричном виде
+func (suite *Iteration14Suite) Hash(data []byte) string {
+	h := hmac.New(sha256.New, suite.key)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
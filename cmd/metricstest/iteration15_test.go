@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
+)
+
+// Iteration15Suite проверяет асимметричное шифрование запросов агента к серверу
+type Iteration15Suite struct {
+	suite.Suite
+
+	serverAddress string
+	processes     *fork.Supervisor
+	agentRecorder *recorder.Recorder
+}
+
+func (suite *Iteration15Suite) SetupSuite() {
+	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
+	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
+	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
+	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
+
+	suite.serverAddress = "http://localhost:" + flagServerPort
+
+	// генерируем пару ключей для агента и сервера и еще один приватный ключ, не соответствующий публичному
+	dir := suite.T().TempDir()
+	privateKey, publicKey, err := writeRSAKeyPair(dir, "valid")
+	suite.Require().NoError(err, "Не удалось сгенерировать пару ключей RSA")
+	wrongKey, _, err := writeRSAKeyPair(dir, "wrong")
+	suite.Require().NoError(err, "Не удалось сгенерировать пару ключей RSA")
+
+	envs := append(os.Environ(), []string{
+		"ADDRESS=localhost:" + flagServerPort,
+		"RESTORE=false",
+		"DATABASE_DSN=" + flagDatabaseDSN,
+		"CRYPTO_KEY=" + privateKey,
+	}...)
+	serverArgs := []string{
+		"-crypto-key=" + privateKey,
+	}
+
+	// сервер с неверным ключом хранит метрики в отдельном файле, чтобы не делить базу данных с основным сервером
+	wrongEnvs := append(os.Environ(), []string{
+		"ADDRESS={{.Addrs.server}}",
+		"RESTORE=false",
+		"DATABASE_DSN=",
+		"FILE_STORAGE_PATH=" + filepath.Join(dir, "metrics.json"),
+		"CRYPTO_KEY=" + wrongKey,
+	}...)
+	wrongArgs := []string{
+		"-crypto-key=" + wrongKey,
+	}
+
+	agentEnvs := append(os.Environ(), []string{
+		"CRYPTO_KEY=" + publicKey,
+		"REPORT_INTERVAL=2",
+		"POLL_INTERVAL=1",
+	}...)
+	agentArgs := []string{
+		"-crypto-key=" + publicKey,
+	}
+
+	// агент отправляет метрики через прокси, записывающий все запросы к серверу
+	rec, err := recorder.New("", "localhost:"+flagServerPort)
+	suite.Require().NoError(err, "Не удалось запустить прокси для записи запросов агента")
+	suite.agentRecorder = rec
+
+	processes, err := fork.NewSupervisor(
+		fork.ProcessSpec{
+			Name:    "server",
+			Command: flagServerBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(envs...), fork.WithArgs(serverArgs...), instrumented.WithCoverDir()},
+			Ready:   []fork.ReadinessProbe{fork.PortProbe("tcp", flagServerPort)},
+		},
+		fork.ProcessSpec{
+			Name:    "wrong-server",
+			Command: flagServerBinaryPath,
+			Opts:    []fork.ProcessOpt{fork.WithEnv(wrongEnvs...), fork.WithArgs(wrongArgs...), fork.WithAutoPort("server"), instrumented.WithCoverDir()},
+			Ready: []fork.ReadinessProbe{func(ctx context.Context, p *fork.BackgroundProcess) error {
+				return p.WaitPort(ctx, "tcp", strconv.Itoa(p.Port("server")))
+			}},
+		},
+		fork.ProcessSpec{
+			Name:      "agent",
+			Command:   flagAgentBinaryPath,
+			Opts:      []fork.ProcessOpt{fork.WithEnv(append(agentEnvs, "ADDRESS="+rec.Addr())...), fork.WithArgs(agentArgs...), instrumented.WithCoverDir()},
+			DependsOn: []string{"server"},
+			Ready: []fork.ReadinessProbe{func(ctx context.Context, _ *fork.BackgroundProcess) error {
+				// ожидаем первого записанного запроса агента
+				return rec.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
+			}},
+		},
+	)
+	suite.Require().NoError(err, "Неожиданная ошибка при описании процессов")
+	suite.processes = processes
+
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
+
+	err = processes.Start(ctx)
+	if err != nil {
+		suite.T().Errorf("Не удалось запустить процессы агента и серверов: %s. Переменные окружения: %+v", err, envs)
+		return
+	}
+}
+
+func (suite *Iteration15Suite) TearDownSuite() {
+	if suite.agentRecorder != nil {
+		defer suite.agentRecorder.Close()
+	}
+	if suite.processes == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results, err := suite.processes.Stop(ctx)
+	if err != nil {
+		suite.T().Logf("Не удалось остановить процессы: %s", err)
+	}
+	for name, res := range results {
+		fork.CheckDataRaces(suite.T(), suite.processes.Process(name))
+		if res.ExitCode > 0 {
+			suite.T().Logf("Процесс %s завершился с не нулевым статусом %d", name, res.ExitCode)
+		}
+	}
+
+	logs := suite.processes.Logs()
+	if len(logs) > 0 {
+		suite.T().Logf("Получен лог процессов:\n\n%s", logs)
+	}
+}
+
+// TestCollectAgentMetrics проверяет, что зашифрованные агентом метрики расшифровываются и сохраняются сервером
+func (suite *Iteration15Suite) TestCollectAgentMetrics() {
+	httpc := resty.New().SetHostURL(suite.serverAddress)
+
+	tests := []struct {
+		name   string
+		method string
+	}{
+		{method: "counter", name: "PollCount"},
+		{method: "gauge", name: "RandomValue"},
+		{method: "gauge", name: "Alloc"},
+	}
+
+	// значения запрашиваются без тела запроса, так как сервер ожидает зашифрованные тела
+	for _, tt := range tests {
+		suite.Run(tt.method+"/"+tt.name, func() {
+			var (
+				resp *resty.Response
+				err  error
+			)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			for ctx.Err() == nil {
+				resp, err = httpc.R().
+					SetContext(ctx).
+					Get("value/" + tt.method + "/" + tt.name)
+				if err == nil && resp.StatusCode() == http.StatusOK {
+					break
+				}
+				time.Sleep(500 * time.Millisecond)
+			}
+
+			if !suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос с получением значения %s", tt.name) {
+				return
+			}
+			suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
+				"Сервер не сохранил значение метрики %s, отправленной агентом", tt.name)
+			_, err = strconv.ParseFloat(string(resp.Body()), 64)
+			suite.Assert().NoErrorf(err, "Сервер вернул некорректное значение метрики %s: %q", tt.name, resp.Body())
+		})
+	}
+}
+
+// TestEncryptedTraffic проверяет, что агент не передает метрики по сети в открытом виде
+func (suite *Iteration15Suite) TestEncryptedTraffic() {
+	suite.Require().NotNil(suite.agentRecorder, "Прокси для записи запросов агента не запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	err := suite.agentRecorder.Wait(ctx, func(reqs []recorder.Request) bool {
+		for _, req := range reqs {
+			if len(req.RawBody) > 0 {
+				return true
+			}
+		}
+		return false
+	})
+	suite.Require().NoError(err, "Не удалось дождаться запросов агента с непустым телом")
+
+	for _, req := range suite.agentRecorder.Requests() {
+		if len(req.RawBody) == 0 {
+			continue
+		}
+		suite.Assert().Equalf(http.StatusOK, req.Status, "Сервер не принял зашифрованный запрос агента: %s", req)
+
+		// тело может быть зашифровано как до, так и после сжатия
+		plain := req.RawBody
+		if req.DecodeErr == nil {
+			plain = req.Body
+		}
+		suite.Assert().Falsef(json.Valid(plain), "Агент передал тело запроса в виде открытого JSON: %s", req)
+		suite.Assert().Falsef(bytes.Contains(plain, []byte("PollCount")) || bytes.Contains(plain, []byte("RandomValue")),
+			"Тело запроса агента содержит имена метрик в открытом виде: %s", req)
+	}
+}
+
+// TestWrongKey проверяет, что сервер с неверным приватным ключом отклоняет зашифрованные запросы агента
+func (suite *Iteration15Suite) TestWrongKey() {
+	suite.Require().NotNil(suite.agentRecorder, "Прокси для записи запросов агента не запущен")
+	suite.Require().NotNil(suite.processes, "Процессы агента и серверов не запущены")
+
+	wrongServer := suite.processes.Process("wrong-server")
+	suite.Require().NotNil(wrongServer, "Сервер с неверным ключом не запущен")
+	wrongAddress := "http://" + wrongServer.Addr("server")
+
+	var replayed int
+	for _, rec := range suite.agentRecorder.Requests() {
+		if len(rec.RawBody) == 0 || rec.Status != http.StatusOK {
+			continue
+		}
+
+		// повторяем записанный запрос агента на сервере с неверным ключом
+		req, err := http.NewRequest(rec.Method, wrongAddress+rec.URL.RequestURI(), bytes.NewReader(rec.RawBody))
+		suite.Require().NoError(err)
+		req.Header = rec.Header.Clone()
+
+		resp, err := http.DefaultClient.Do(req)
+		if !suite.Assert().NoErrorf(err, "Ошибка при попытке повторить запрос агента %s", rec) {
+			continue
+		}
+		resp.Body.Close()
+
+		suite.Assert().GreaterOrEqualf(resp.StatusCode, http.StatusBadRequest,
+			"Сервер с неверным ключом должен отклонять запрос агента %s, получен статус %d", rec, resp.StatusCode)
+		replayed++
+	}
+	suite.Assert().Positive(replayed, "Не найдено ни одного принятого сервером запроса агента для проверки")
+}
+
+// writeRSAKeyPair генерирует пару ключей RSA и записывает их в директорию dir в формате PEM.
+// Возвращает пути до файлов приватного и публичного ключей
+func writeRSAKeyPair(dir, name string) (privatePath, publicPath string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	if err != nil {
+		return "", "", err
+	}
+
+	privatePath = filepath.Join(dir, name+".pem")
+	privatePEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	if err := os.WriteFile(privatePath, privatePEM, 0o600); err != nil {
+		return "", "", err
+	}
+
+	publicPath = filepath.Join(dir, name+".pub.pem")
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey),
+	})
+	if err := os.WriteFile(publicPath, publicPEM, 0o600); err != nil {
+		return "", "", err
+	}
+	return privatePath, publicPath, nil
+}
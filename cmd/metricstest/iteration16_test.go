@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
+)
+
+// realIPHeader заголовок, в котором агент передает свой IP адрес
+const realIPHeader = "X-Real-IP"
+
+// Iteration16Suite проверяет, что сервер принимает обновления метрик только из доверенной подсети
+type Iteration16Suite struct {
+	suite.Suite
+
+	agentProcess *fork.BackgroundProcess
+	// agentServer является поддельным сервером, записывающим запросы агента
+	agentServer *recorder.Recorder
+
+	rnd *rand.Rand
+}
+
+func (suite *Iteration16Suite) SetupSuite() {
+	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
+	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
+	suite.Require().NotEmpty(flagDatabaseDSN, "-database-dsn non-empty flag required")
+
+	suite.rnd = random.NewRand(suite.T().Name())
+
+	srv, err := recorder.NewFake("")
+	suite.Require().NoError(err, "Не удалось запустить поддельный сервер для записи запросов агента")
+	suite.agentServer = srv
+
+	envs := append(os.Environ(), []string{
+		"ADDRESS=" + srv.Addr(),
+		"REPORT_INTERVAL=2",
+		"POLL_INTERVAL=1",
+	}...)
+	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
+		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err = suite.agentProcess.Start(ctx)
+	if err != nil {
+		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", suite.agentProcess, err, envs)
+		return
+	}
+}
+
+func (suite *Iteration16Suite) TearDownSuite() {
+	if suite.agentServer != nil {
+		defer suite.agentServer.Close()
+	}
+	if suite.agentProcess == nil {
+		return
+	}
+
+	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return
+		}
+		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		return
+	}
+
+	if exitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	out := suite.agentProcess.Stderr(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	}
+	out = suite.agentProcess.Stdout(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	}
+}
+
+// TestAgentRealIP проверяет, что агент передает свой IP адрес в заголовке X-Real-IP
+func (suite *Iteration16Suite) TestAgentRealIP() {
+	suite.Require().NotNil(suite.agentServer, "Поддельный сервер для записи запросов агента не запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	err := suite.agentServer.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
+	suite.Require().NoError(err, "Не удалось дождаться запросов агента")
+
+	addrs, err := net.InterfaceAddrs()
+	suite.Require().NoError(err, "Не удалось получить список адресов сетевых интерфейсов")
+
+	for _, req := range suite.agentServer.Requests() {
+		header := req.Header.Get(realIPHeader)
+		if !suite.Assert().NotEmptyf(header, "Агент отправил запрос без заголовка %s: %s", realIPHeader, req) {
+			continue
+		}
+		ip := net.ParseIP(header)
+		if !suite.Assert().NotNilf(ip, "Агент передал в заголовке %s некорректный IP адрес %q: %s", realIPHeader, header, req) {
+			continue
+		}
+		suite.Assert().Truef(hasInterfaceIP(addrs, ip),
+			"Агент передал в заголовке %s адрес %s, не принадлежащий ни одному сетевому интерфейсу: %s", realIPHeader, ip, req)
+	}
+}
+
+// TestTrustedSubnet проверяет для нескольких случайных подсетей:
+// - сервер принимает обновления с адресом X-Real-IP из доверенной подсети
+// - сервер отклоняет обновления с адресом вне доверенной подсети или без заголовка X-Real-IP
+func (suite *Iteration16Suite) TestTrustedSubnet() {
+	for range 3 {
+		subnet := randomSubnet(suite.rnd)
+		suite.Run(strings.ReplaceAll(subnet.String(), "/", "_"), func() {
+			suite.checkSubnet(subnet)
+		})
+	}
+}
+
+// checkSubnet запускает сервер с доверенной подсетью subnet и проверяет ответы на запросы из разных адресов
+func (suite *Iteration16Suite) checkSubnet(subnet *net.IPNet) {
+	envs := append(os.Environ(), []string{
+		"ADDRESS={{.Addrs.server}}",
+		"RESTORE=false",
+		"DATABASE_DSN=" + flagDatabaseDSN,
+		"TRUSTED_SUBNET=" + subnet.String(),
+	}...)
+	args := []string{
+		"-t=" + subnet.String(),
+	}
+	server := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
+		fork.WithEnv(envs...),
+		fork.WithArgs(args...),
+		fork.WithAutoPort("server"),
+		instrumented.WithCoverDir(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err := server.Start(ctx)
+	suite.Require().NoErrorf(err, "Невозможно запустить процесс командой %q. Переменные окружения: %+v, флаги командной строки: %+v", server, envs, args)
+	defer suite.serverShutdown(server)
+
+	port := strconv.Itoa(server.Port("server"))
+	err = server.WaitPort(ctx, "tcp", port)
+	suite.Require().NoErrorf(err, "Не удалось дождаться пока порт %s станет доступен для запроса", port)
+
+	httpc := resty.New().SetHostURL("http://" + server.Addr("server"))
+
+	id := "Trusted" + random.ASCIIString(8, 16)
+	value := suite.rnd.Float64() * 1e6
+	metric := Metrics{ID: id, MType: "gauge", Value: &value}
+
+	ips := []struct {
+		name   string
+		ip     string
+		status int
+	}{
+		{name: "inside", ip: randomIPInside(suite.rnd, subnet).String(), status: http.StatusOK},
+		{name: "outside", ip: randomIPOutside(suite.rnd, subnet).String(), status: http.StatusForbidden},
+		{name: "no_header", status: http.StatusForbidden},
+	}
+	endpoints := []struct {
+		path string
+		body any
+	}{
+		{path: "update/", body: &metric},
+		{path: "updates/", body: []Metrics{metric}},
+	}
+
+	for _, tt := range ips {
+		for _, ep := range endpoints {
+			suite.Run(tt.name+"/"+strings.TrimSuffix(ep.path, "/"), func() {
+				req := httpc.R().
+					SetHeader("Content-Type", "application/json").
+					SetBody(ep.body)
+				if tt.ip != "" {
+					req.SetHeader(realIPHeader, tt.ip)
+				}
+				resp, err := req.Post(ep.path)
+
+				dumpErr := suite.Assert().NoError(err,
+					"Ошибка при попытке сделать запрос с обновлением gauge")
+				dumpErr = dumpErr && suite.Assert().Equalf(tt.status, resp.StatusCode(),
+					"Несоответствие статус кода ответа ожидаемому для адреса %q и доверенной подсети %s в хендлере %q: %q",
+					tt.ip, subnet, req.Method, req.URL)
+
+				if !dumpErr {
+					dump := dumpRequest(req.RawRequest, true)
+					suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+					dump = dumpResponse(resp.RawResponse, true)
+					suite.T().Logf("Оригинальный ответ:\n\n%s", dump)
+				}
+			})
+		}
+	}
+}
+
+func (suite *Iteration16Suite) serverShutdown(server *fork.BackgroundProcess) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	res, err := server.StopGraceful(ctx, 10*time.Second)
+	fork.CheckDataRaces(suite.T(), server)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return
+		}
+		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		return
+	}
+
+	if res.ExitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", res.ExitCode)
+	}
+
+	if !suite.T().Failed() {
+		return
+	}
+
+	outCtx, outCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer outCancel()
+
+	out := server.Stderr(outCtx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	}
+	out = server.Stdout(outCtx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	}
+}
+
+// randomSubnet возвращает случайную IPv4 подсеть с длиной префикса от 8 до 28 бит
+func randomSubnet(rnd *rand.Rand) *net.IPNet {
+	ones := 8 + rnd.Intn(21)
+	mask := net.CIDRMask(ones, 32)
+
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, rnd.Uint32())
+	// исключаем подсеть loopback, чтобы запросы из нее не считались доверенными случайно
+	if ip[0] == 127 {
+		ip[0] = 10
+	}
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// randomIPInside возвращает случайный адрес из подсети subnet
+func randomIPInside(rnd *rand.Rand, subnet *net.IPNet) net.IP {
+	ones, bits := subnet.Mask.Size()
+	host := rnd.Uint32() & (1<<(bits-ones) - 1)
+
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(subnet.IP.To4())|host)
+	return ip
+}
+
+// randomIPOutside возвращает случайный адрес вне подсети subnet, отличающийся от нее одним битом префикса
+func randomIPOutside(rnd *rand.Rand, subnet *net.IPNet) net.IP {
+	ones, bits := subnet.Mask.Size()
+	ip := randomIPInside(rnd, subnet)
+	flip := uint32(1) << (bits - 1 - rnd.Intn(ones))
+	binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(ip)^flip)
+	return ip
+}
+
+// hasInterfaceIP проверяет, принадлежит ли адрес ip одному из адресов сетевых интерфейсов
+func hasInterfaceIP(addrs []net.Addr, ip net.IP) bool {
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -32,6 +32,7 @@ func (suite *Iteration1Suite) SetupSuite() {
 	}...)
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -57,6 +58,7 @@ func (suite *Iteration1Suite) TearDownSuite() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
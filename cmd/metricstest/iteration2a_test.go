@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"net/http"
 	"os"
 	"syscall"
 	"time"
@@ -10,19 +11,35 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
 )
 
+// agentRuntimeGauges содержит имена метрик пакета runtime, которые должен собирать агент
+var agentRuntimeGauges = []string{
+	"Alloc", "BuckHashSys", "Frees", "GCCPUFraction", "GCSys", "HeapAlloc", "HeapIdle",
+	"HeapInuse", "HeapObjects", "HeapReleased", "HeapSys", "LastGC", "Lookups", "MCacheInuse",
+	"MCacheSys", "MSpanInuse", "MSpanSys", "Mallocs", "NextGC", "NumForcedGC", "NumGC",
+	"OtherSys", "PauseTotalNs", "StackInuse", "StackSys", "Sys", "TotalAlloc",
+}
+
 type Iteration2ASuite struct {
 	suite.Suite
 
 	agentAddress string
 	agentProcess *fork.BackgroundProcess
+	// agentServer является поддельным сервером, записывающим запросы агента
+	agentServer *recorder.Recorder
 }
 
 func (suite *Iteration2ASuite) SetupSuite() {
 	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
 
-	suite.agentAddress = "http://localhost:8080"
+	suite.agentAddress = "localhost:8080"
+
+	// на данной итерации агент отправляет метрики только на адрес по умолчанию
+	srv, err := recorder.NewFake(suite.agentAddress)
+	suite.Require().NoErrorf(err, "Не удалось запустить поддельный сервер на адресе %s", suite.agentAddress)
+	suite.agentServer = srv
 
 	// Для обеспечения обратной совместимости с будущими заданиями
 	envs := append(os.Environ(), []string{
@@ -30,31 +47,35 @@ func (suite *Iteration2ASuite) SetupSuite() {
 	}...)
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	err := suite.agentProcess.Start(ctx)
+	err = suite.agentProcess.Start(ctx)
 	if err != nil {
 		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", suite.agentProcess, err, envs)
 		return
 	}
 
-	port := "8080"
-	err = suite.agentProcess.ListenPort(ctx, "tcp", port)
+	err = suite.agentServer.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
 	if err != nil {
-		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
+		suite.T().Errorf("Не удалось дождаться пока агент начнет отправлять запросы на адрес %s: %s", suite.agentAddress, err)
 		return
 	}
 }
 
 func (suite *Iteration2ASuite) TearDownSuite() {
+	if suite.agentServer != nil {
+		_ = suite.agentServer.Close()
+	}
 	if suite.agentProcess == nil {
 		return
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -82,8 +103,66 @@ func (suite *Iteration2ASuite) TearDownSuite() {
 }
 
 // TestAgent проверяет:
-// - агент успешно стартует и передает какие-нибудь данные по tcp, на localhost:8080
+// - агент успешно стартует и передает данные на localhost:8080
+// - агент передает метрики runtime, PollCount и RandomValue запросами POST /update/{type}/{name}/{value}
+// - запросы имеют заголовок Content-Type: text/plain, а значения метрик корректны для своего типа
 func (suite *Iteration2ASuite) TestAgent() {
+	suite.Require().NotNil(suite.agentServer, "Поддельный сервер для записи запросов агента не запущен")
+
+	expected := map[string]string{
+		"PollCount":   "counter",
+		"RandomValue": "gauge",
+	}
+	for _, name := range agentRuntimeGauges {
+		expected[name] = "gauge"
+	}
+
 	suite.Run("receive data from agent", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		err := suite.agentServer.Wait(ctx, func([]recorder.Request) bool {
+			updates, _ := suite.agentServer.Updates()
+			received := make(map[string]bool, len(expected))
+			for _, u := range updates {
+				received[u.ID] = true
+			}
+			for name := range expected {
+				if !received[name] {
+					return false
+				}
+			}
+			return true
+		})
+		suite.Assert().NoError(err, "Не удалось дождаться получения всех метрик от агента")
 	})
+
+	suite.Run("request format", func() {
+		for _, req := range suite.agentServer.Requests() {
+			suite.Assert().Equalf(http.MethodPost, req.Method, "Агент отправил запрос с неожиданным методом: %s", req)
+			suite.Assert().Containsf(req.Header.Get("Content-Type"), "text/plain",
+				"Агент отправил запрос с несоответствующим заголовком Content-Type: %s", req)
+			suite.Assert().Equalf(http.StatusOK, req.Status, "Запрос агента не соответствует API сервера: %s", req)
+		}
+
+		_, err := suite.agentServer.Updates()
+		suite.Assert().NoError(err, "Не удалось разобрать запросы агента")
+	})
+
+	for name, mtype := range expected {
+		suite.Run(mtype+"/"+name, func() {
+			updates := suite.agentServer.UpdatesFor(name)
+			if !suite.Assert().NotEmptyf(updates, "Агент не отправил значение метрики %s", name) {
+				return
+			}
+			for _, u := range updates {
+				suite.Assert().Equalf(recorder.SourcePath, u.Source,
+					"Агент должен отправлять метрику %s запросом вида /update/%s/%s/<значение>", name, mtype, name)
+				suite.Assert().Equalf(mtype, u.MType, "Метрика %s должна иметь тип %s", name, mtype)
+			}
+			if mtype == "counter" && updates[0].Delta != nil {
+				suite.Assert().Positivef(*updates[0].Delta, "Значение %s должно быть положительным", name)
+			}
+		})
+	}
 }
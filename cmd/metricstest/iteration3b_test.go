@@ -16,6 +16,8 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/prop"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration3BSuite struct {
@@ -23,11 +25,15 @@ type Iteration3BSuite struct {
 
 	serverAddress string
 	serverProcess *fork.BackgroundProcess
+
+	rnd *rand.Rand
 }
 
 func (suite *Iteration3BSuite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 
+	suite.rnd = random.NewRand(suite.T().Name())
+
 	suite.serverAddress = "http://localhost:8080"
 
 	// Для обеспечения обратной совместимости с будущими заданиями
@@ -36,6 +42,7 @@ func (suite *Iteration3BSuite) SetupSuite() {
 	}...)
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -61,6 +68,7 @@ func (suite *Iteration3BSuite) TearDownSuite() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -95,10 +103,10 @@ func (suite *Iteration3BSuite) TestGauge() {
 
 	count := 3
 	suite.Run("update sequence", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		for i := 0; i < count; i++ {
-			v := strings.TrimRight(fmt.Sprintf("%.3f", rand.Float64()*1000000), "0.")
+			v := strings.TrimRight(fmt.Sprintf("%.3f", suite.rnd.Float64()*1000000), "0.")
 			resp, err := req.Post("update/gauge/testSetGet" + id + "/" + v)
 			noRespErr := suite.Assert().NoError(err,
 				"Ошибка при попытке сделать запрос с обновлением gauge")
@@ -127,7 +135,7 @@ func (suite *Iteration3BSuite) TestGauge() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/gauge/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err,
@@ -152,7 +160,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 	count := 3
 	suite.Run("update sequence", func() {
 		req := httpc.R()
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		resp, err := req.Get("value/counter/testSetGet" + id)
 		noRespErr := suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос для получения значения counter")
@@ -166,7 +174,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 		a, _ := strconv.ParseInt(resp.String(), 0, 64)
 
 		for i := 0; i < count; i++ {
-			v := rand.Intn(1024)
+			v := suite.rnd.Intn(1024)
 			a += int64(v)
 			resp, err = req.Post("update/counter/testSetGet" + id + "/" + strconv.Itoa(v))
 
@@ -197,7 +205,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/counter/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
@@ -210,3 +218,108 @@ func (suite *Iteration3BSuite) TestCounter() {
 		}
 	})
 }
+
+func (suite *Iteration3BSuite) TestEdgeCases() {
+	httpc := resty.NewWithClient(&http.Client{
+		Transport: &http.Transport{
+			DisableCompression: true,
+		},
+	}).SetHostURL(suite.serverAddress)
+
+	for _, c := range random.MetricCases() {
+		suite.Run(c.Class, func() {
+			if c.Extreme && !flagExtremeEdgeCases {
+				suite.T().Skip("предельные значения и идентификаторы метрик проверяются с флагом -extreme-edge-cases")
+			}
+
+			req := httpc.R()
+			for _, path := range c.UpdatePaths() {
+				resp, err := req.Post(path)
+				noRespErr := suite.Assert().NoErrorf(err,
+					"Ошибка при попытке сделать запрос с обновлением %s", c.MType())
+
+				// переполнение int64 сервер вправе отклонить, но не должен завершаться с внутренней ошибкой
+				var validStatus bool
+				if c.Overflow {
+					validStatus = suite.Assert().Lessf(resp.StatusCode(), http.StatusInternalServerError,
+						"Сервер вернул внутреннюю ошибку при переполнении значения counter в хендлере '%s %s'", req.Method, req.URL)
+				} else {
+					validStatus = suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
+						"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+				}
+
+				if !noRespErr || !validStatus {
+					dump := dumpRequest(req.RawRequest, true)
+					suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+					return
+				}
+			}
+
+			resp, err := req.Get(c.ValuePath())
+			noRespErr := suite.Assert().NoErrorf(err,
+				"Ошибка при попытке сделать запрос для получения значения %s", c.MType())
+			validStatus := suite.Assert().Equalf(http.StatusOK, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+
+			equality := true
+			switch {
+			case c.Overflow:
+			case c.MType() == random.Gauge:
+				// представление gauge в тексте не фиксировано, поэтому сравниваются сами значения
+				expected := *c.Updates[len(c.Updates)-1].Value
+				value, parseErr := strconv.ParseFloat(resp.String(), 64)
+				equality = suite.Assert().NoErrorf(parseErr,
+					"Не удалось разобрать значение gauge (%s) полученное от сервера, '%s %s'", resp.String(), req.Method, req.URL)
+				equality = equality && suite.Assert().Equalf(expected, value,
+					"Несоответствие ожидаемого значения gauge (%s) полученному от сервера (%s), '%s %s'", c.Expected, resp.String(), req.Method, req.URL)
+			default:
+				equality = suite.Assert().Equalf(c.Expected, resp.String(),
+					"Несоответствие ожидаемого значения %s (%s) полученному от сервера (%s), '%s %s'", c.MType(), c.Expected, resp.String(), req.Method, req.URL)
+			}
+
+			if !noRespErr || !validStatus || !equality {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			}
+		})
+	}
+}
+
+// TestCounterProperty проверяет свойство: для любой последовательности приращений counter
+// сервер возвращает их сумму. При нарушении выводится минимальная найденная последовательность
+func (suite *Iteration3BSuite) TestCounterProperty() {
+	httpc := resty.NewWithClient(&http.Client{
+		Transport: &http.Transport{
+			DisableCompression: true,
+		},
+	}).SetHostURL(suite.serverAddress)
+
+	prop.Check(suite.T(), prop.CounterDeltas(10), func(deltas []int64) error {
+		// каждая проверка использует новую метрику, чтобы значения не накапливались
+		id := "testProperty" + random.ASCIIString(10, 20)
+
+		var sum int64
+		for _, d := range deltas {
+			sum += d
+			resp, err := httpc.R().Post("update/counter/" + id + "/" + strconv.FormatInt(d, 10))
+			if err != nil {
+				return fmt.Errorf("ошибка при попытке сделать запрос для обновления значения counter: %w", err)
+			}
+			if resp.StatusCode() != http.StatusOK {
+				return fmt.Errorf("хендлер обновления вернул статус %d вместо %d", resp.StatusCode(), http.StatusOK)
+			}
+		}
+
+		resp, err := httpc.R().Get("value/counter/" + id)
+		if err != nil {
+			return fmt.Errorf("ошибка при попытке сделать запрос для получения значения counter: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("хендлер получения значения вернул статус %d вместо %d", resp.StatusCode(), http.StatusOK)
+		}
+		if resp.String() != strconv.FormatInt(sum, 10) {
+			return fmt.Errorf("получено значение %s вместо суммы приращений %d", resp.String(), sum)
+		}
+		return nil
+	}, prop.WithRuns(20))
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration4Suite struct {
@@ -38,7 +39,7 @@ func (suite *Iteration4Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagTargetSourcePath, "-source-path non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -66,6 +67,7 @@ func (suite *Iteration4Suite) serverUp(ctx context.Context, envs, args []string,
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -85,6 +87,7 @@ func (suite *Iteration4Suite) agentUp(ctx context.Context, envs, args []string,
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.agentProcess.Start(ctx)
@@ -111,6 +114,7 @@ func (suite *Iteration4Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -142,6 +146,7 @@ func (suite *Iteration4Suite) agentShutdown() {
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -176,10 +181,10 @@ func (suite *Iteration4Suite) TestGauge() {
 
 	count := 3
 	suite.Run("update sequence", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		for i := 0; i < count; i++ {
-			v := strings.TrimRight(fmt.Sprintf("%.3f", rand.Float64()*1000000), "0.")
+			v := strings.TrimRight(fmt.Sprintf("%.3f", suite.rnd.Float64()*1000000), "0.")
 			resp, err := req.Post("update/gauge/testSetGet" + id + "/" + v)
 			noRespErr := suite.Assert().NoError(err,
 				"Ошибка при попытке сделать запрос с обновлением gauge")
@@ -208,7 +213,7 @@ func (suite *Iteration4Suite) TestGauge() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/gauge/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err,
@@ -233,7 +238,7 @@ func (suite *Iteration4Suite) TestCounter() {
 	count := 3
 	suite.Run("update sequence", func() {
 		req := httpc.R()
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		resp, err := req.Get("value/counter/testSetGet" + id)
 		noRespErr := suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос для получения значения counter")
@@ -247,7 +252,7 @@ func (suite *Iteration4Suite) TestCounter() {
 		a, _ := strconv.ParseInt(resp.String(), 0, 64)
 
 		for i := 0; i < count; i++ {
-			v := rand.Intn(1024)
+			v := suite.rnd.Intn(1024)
 			a += int64(v)
 			resp, err = req.Post("update/counter/testSetGet" + id + "/" + strconv.Itoa(v))
 
@@ -278,7 +283,7 @@ func (suite *Iteration4Suite) TestCounter() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/counter/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
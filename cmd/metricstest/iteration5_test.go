@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration5Suite struct {
@@ -24,6 +25,8 @@ type Iteration5Suite struct {
 	serverAddress string
 	serverProcess *fork.BackgroundProcess
 	agentProcess  *fork.BackgroundProcess
+
+	rnd *rand.Rand
 }
 
 func (suite *Iteration5Suite) SetupSuite() {
@@ -32,6 +35,8 @@ func (suite *Iteration5Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 
+	suite.rnd = random.NewRand(suite.T().Name())
+
 	suite.serverAddress = "http://localhost:" + flagServerPort
 
 	envs := append(os.Environ(), []string{
@@ -52,6 +57,7 @@ func (suite *Iteration5Suite) SetupSuite() {
 func (suite *Iteration5Suite) serverUp(ctx context.Context, envs []string, port string) {
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -70,6 +76,7 @@ func (suite *Iteration5Suite) serverUp(ctx context.Context, envs []string, port
 func (suite *Iteration5Suite) agentUp(ctx context.Context, envs []string, port string) {
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.agentProcess.Start(ctx)
@@ -96,6 +103,7 @@ func (suite *Iteration5Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -127,6 +135,7 @@ func (suite *Iteration5Suite) agentShutdown() {
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -161,10 +170,10 @@ func (suite *Iteration5Suite) TestGauge() {
 
 	count := 3
 	suite.Run("update sequence", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		for i := 0; i < count; i++ {
-			v := strings.TrimRight(fmt.Sprintf("%.3f", rand.Float64()*1000000), "0")
+			v := strings.TrimRight(fmt.Sprintf("%.3f", suite.rnd.Float64()*1000000), "0")
 			v = strings.TrimRight(v, ".")
 			resp, err := req.Post("update/gauge/testSetGet" + id + "/" + v)
 			noRespErr := suite.Assert().NoError(err,
@@ -194,7 +203,7 @@ func (suite *Iteration5Suite) TestGauge() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/gauge/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err,
@@ -219,7 +228,7 @@ func (suite *Iteration5Suite) TestCounter() {
 	count := 3
 	suite.Run("update sequence", func() {
 		req := httpc.R()
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		resp, err := req.Get("value/counter/testSetGet" + id)
 		noRespErr := suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос для получения значения counter")
@@ -233,7 +242,7 @@ func (suite *Iteration5Suite) TestCounter() {
 		a, _ := strconv.ParseInt(resp.String(), 0, 64)
 
 		for i := 0; i < count; i++ {
-			v := rand.Intn(1024)
+			v := suite.rnd.Intn(1024)
 			a += int64(v)
 			resp, err = req.Post("update/counter/testSetGet" + id + "/" + strconv.Itoa(v))
 
@@ -264,7 +273,7 @@ func (suite *Iteration5Suite) TestCounter() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/counter/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
@@ -1,21 +1,41 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/logscan"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration6Suite struct {
 	suite.Suite
 
 	knownLoggers PackageRules
+
+	serverAddress string
+	serverProcess *fork.BackgroundProcess
+	serverLogs    *logscan.Collector
+
+	rnd *rand.Rand
 }
 
 // SetupSuite подготавливает необходимые зависимости
 func (suite *Iteration6Suite) SetupSuite() {
 	// проверяем наличие необходимых флагов
 	suite.Require().NotEmpty(flagTargetSourcePath, "-source-path non-empty flag required")
+	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
+
+	suite.rnd = random.NewRand(suite.T().Name())
 
 	// список известных логгеров
 	suite.knownLoggers = PackageRules{
@@ -33,6 +53,69 @@ func (suite *Iteration6Suite) SetupSuite() {
 		// "gopkg.in/inconshreveable/log15.v2",
 		// "log",
 	}
+
+	// запускаем сервер, собирая структурированные записи из его вывода
+	suite.serverLogs = logscan.NewCollector()
+	envs := append(os.Environ(), "ADDRESS={{.Addrs.server}}", "RESTORE=false")
+	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
+		fork.WithEnv(envs...),
+		fork.WithAutoPort("server"),
+		fork.WithLineHandler(suite.serverLogs.Handle),
+		instrumented.WithCoverDir(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err := suite.serverProcess.Start(ctx)
+	if err != nil {
+		suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", suite.serverProcess, err, envs)
+		return
+	}
+
+	port := strconv.Itoa(suite.serverProcess.Port("server"))
+	err = suite.serverProcess.WaitReady(ctx, fork.PortProbe("tcp", port))
+	if err != nil {
+		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
+		return
+	}
+	suite.serverAddress = "http://" + suite.serverProcess.Addr("server")
+}
+
+// TearDownSuite высвобождает имеющиеся зависимости
+func (suite *Iteration6Suite) TearDownSuite() {
+	if suite.serverProcess == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	res, err := suite.serverProcess.StopGraceful(ctx, 10*time.Second)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return
+		}
+		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		return
+	}
+
+	if res.ExitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", res.ExitCode)
+	}
+
+	outCtx, outCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer outCancel()
+
+	out := suite.serverProcess.Stderr(outCtx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	}
+	out = suite.serverProcess.Stdout(outCtx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	}
 }
 
 // TestLoggerUsage пробует рекурсивно найти хотя бы одно использование известных логгеров в директории с исходным кодом проекта
@@ -48,3 +131,63 @@ func (suite *Iteration6Suite) TestLoggerUsage() {
 	}
 	suite.T().Errorf("Неожиданная ошибка при поиске использования логгера по пути %s: %s", flagTargetSourcePath, err)
 }
+
+// TestRequestLogging проверяет, что сервер записывает в лог сведения о каждом запросе и ответе на него
+func (suite *Iteration6Suite) TestRequestLogging() {
+	httpc := resty.New().SetHostURL(suite.serverAddress)
+
+	id := "Logged" + strconv.Itoa(suite.rnd.Intn(256))
+	value := strconv.Itoa(suite.rnd.Intn(1024))
+
+	suite.Run("update", func() {
+		suite.checkRequestLogged(httpc, http.MethodPost, "/update/counter/"+id+"/"+value, http.StatusOK)
+	})
+
+	suite.Run("get", func() {
+		suite.checkRequestLogged(httpc, http.MethodGet, "/value/counter/"+id, http.StatusOK)
+	})
+
+	suite.Run("get_unknown", func() {
+		suite.checkRequestLogged(httpc, http.MethodGet, "/value/gauge/unknown"+id, http.StatusNotFound)
+	})
+}
+
+// checkRequestLogged выполняет запрос и проверяет записи лога сервера, относящиеся к нему
+func (suite *Iteration6Suite) checkRequestLogged(httpc *resty.Client, method, uri string, expectedStatus int) {
+	since := time.Now()
+	resp, err := httpc.R().
+		SetHeader("Content-Type", "text/plain").
+		Execute(method, uri)
+
+	dumpErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос %s %s", method, uri)
+	dumpErr = dumpErr && suite.Assert().Equalf(expectedStatus, resp.StatusCode(),
+		"Несоответствие статус кода ответа ожидаемому в хендлере %q: %q ", method, uri)
+	if !dumpErr {
+		dump := dumpRequest(resp.Request.RawRequest, true)
+		suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rec, err := suite.serverLogs.WaitRequest(ctx, since, method, uri)
+	if err != nil {
+		suite.T().Errorf("Не удалось найти в логе сервера сведения о запросе %s %s: %s\n"+
+			"Сведения о запросе должны содержать URI, метод и время выполнения, сведения об ответе - код статуса и размер содержимого.\n"+
+			"Найденные записи:\n%s\nВсего строк вывода: %d, распознано структурированных записей: %d",
+			method, uri, err, rec, suite.serverLogs.Lines(), len(suite.serverLogs.Entries()))
+		return
+	}
+
+	status, ok := rec.Status()
+	suite.Assert().Truef(ok && status == resp.StatusCode(),
+		"Код статуса в логе (%q) не соответствует коду статуса ответа %d на запрос %s %s:\n%s",
+		rec.Fields[logscan.FieldStatus], resp.StatusCode(), method, uri, rec)
+
+	// размер сжатого ответа не совпадает с размером распакованного тела
+	if size, ok := rec.Size(); ok && !resp.RawResponse.Uncompressed {
+		suite.Assert().Equalf(len(resp.Body()), size,
+			"Размер содержимого в логе не соответствует размеру ответа на запрос %s %s:\n%s", method, uri, rec)
+	}
+}
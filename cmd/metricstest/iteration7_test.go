@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+	"github.com/Yandex-Practicum/go-autotests/internal/recorder"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 )
@@ -21,6 +23,7 @@ type Iteration7Suite struct {
 	serverAddress string
 	serverProcess *fork.BackgroundProcess
 	agentProcess  *fork.BackgroundProcess
+	agentRecorder *recorder.Recorder
 
 	knownEncodingLibs PackageRules
 
@@ -39,7 +42,7 @@ func (suite *Iteration7Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 	suite.Require().NotEmpty(flagAgentBinaryPath, "-agent-binary-path non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 
 	suite.knownEncodingLibs = PackageRules{
 		{Name: "encoding/json"},
@@ -57,13 +60,22 @@ func (suite *Iteration7Suite) SetupSuite() {
 	}...)
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	suite.agentUp(ctx, envs, "8080")
-	suite.serverUp(ctx, envs, "8080")
+	suite.serverUp(ctx, append(envs, "ADDRESS=localhost:8080"), "8080")
+
+	// агент отправляет метрики через прокси, записывающий все запросы к серверу
+	rec, err := recorder.New("", "localhost:8080")
+	if err != nil {
+		suite.T().Errorf("Не удалось запустить прокси для записи запросов агента: %s", err)
+		return
+	}
+	suite.agentRecorder = rec
+	suite.agentUp(ctx, append(envs, "ADDRESS="+rec.Addr()), "")
 }
 
 func (suite *Iteration7Suite) serverUp(ctx context.Context, envs []string, port string) {
 	suite.serverProcess = fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.serverProcess.Start(ctx)
@@ -82,6 +94,7 @@ func (suite *Iteration7Suite) serverUp(ctx context.Context, envs []string, port
 func (suite *Iteration7Suite) agentUp(ctx context.Context, envs []string, port string) {
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.agentProcess.Start(ctx)
@@ -90,6 +103,15 @@ func (suite *Iteration7Suite) agentUp(ctx context.Context, envs []string, port s
 		return
 	}
 
+	// при работе через прокси ожидаем первого записанного запроса агента
+	if suite.agentRecorder != nil {
+		err = suite.agentRecorder.Wait(ctx, func(reqs []recorder.Request) bool { return len(reqs) > 0 })
+		if err != nil {
+			suite.T().Errorf("Не удалось дождаться пока агент начнет отправлять запросы на адрес %s: %s", suite.agentRecorder.Addr(), err)
+		}
+		return
+	}
+
 	err = suite.agentProcess.ListenPort(ctx, "tcp", port)
 	if err != nil {
 		suite.T().Errorf("Не удалось дождаться пока на порт %s начнут поступать данные: %s", port, err)
@@ -100,6 +122,9 @@ func (suite *Iteration7Suite) agentUp(ctx context.Context, envs []string, port s
 func (suite *Iteration7Suite) TearDownSuite() {
 	suite.agentShutdown()
 	suite.serverShutdown()
+	if suite.agentRecorder != nil {
+		_ = suite.agentRecorder.Close()
+	}
 }
 
 func (suite *Iteration7Suite) serverShutdown() {
@@ -108,6 +133,7 @@ func (suite *Iteration7Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -139,6 +165,7 @@ func (suite *Iteration7Suite) agentShutdown() {
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -452,3 +479,49 @@ cont:
 		})
 	}
 }
+
+// TestAgentRequests проверяет запросы, которые агент отправляет серверу через JSON API
+func (suite *Iteration7Suite) TestAgentRequests() {
+	suite.Require().NotNil(suite.agentRecorder, "Прокси для записи запросов агента не запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, id := range []string{"PollCount", "RandomValue"} {
+		_, err := suite.agentRecorder.WaitUpdates(ctx, id, 1)
+		suite.Require().NoErrorf(err, "Агент не отправил значение метрики %s", id)
+	}
+
+	for _, req := range suite.agentRecorder.Requests() {
+		suite.Assert().Equalf(http.MethodPost, req.Method, "Агент отправил запрос с неожиданным методом: %s", req)
+		suite.Assert().Containsf(req.Header.Get("Content-Type"), "application/json",
+			"Агент отправил запрос с несоответствующим заголовком Content-Type: %s", req)
+		suite.Assert().Equalf(http.StatusOK, req.Status, "Сервер не принял запрос агента: %s", req)
+	}
+
+	updates, err := suite.agentRecorder.Updates()
+	suite.Assert().NoError(err, "Не удалось разобрать запросы агента")
+
+	for _, u := range updates {
+		suite.Assert().NotEqualf(recorder.SourcePath, u.Source,
+			"Агент должен отправлять метрики в формате JSON, получен запрос вида /update/%s/%s/...", u.MType, u.ID)
+
+		switch u.MType {
+		case "counter":
+			suite.Assert().Truef(u.Delta != nil && u.Value == nil,
+				"Агент отправил counter %s без значения delta или со значением value", u.ID)
+		case "gauge":
+			suite.Assert().Truef(u.Value != nil && u.Delta == nil,
+				"Агент отправил gauge %s без значения value или со значением delta", u.ID)
+		default:
+			suite.Assert().Failf("Неизвестный тип метрики", "Агент отправил метрику %s неизвестного типа %q", u.ID, u.MType)
+		}
+	}
+
+	poll := suite.agentRecorder.UpdatesFor("PollCount")
+	suite.Require().NotEmpty(poll)
+	suite.Assert().Equal("counter", poll[0].MType, "Метрика PollCount должна иметь тип counter")
+	if poll[0].Delta != nil {
+		suite.Assert().Positive(*poll[0].Delta, "Значение PollCount должно быть положительным")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 )
@@ -35,7 +36,7 @@ func (suite *Iteration9Suite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerPort, "-server-port non-empty flag required")
 	suite.Require().NotEmpty(flagFileStoragePath, "-file-storage-path non-empty flag required")
 
-	suite.rnd = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	suite.rnd = random.NewRand(suite.T().Name())
 	suite.serverAddress = "http://localhost:" + flagServerPort
 	suite.serverPort = flagServerPort
 
@@ -56,6 +57,7 @@ func (suite *Iteration9Suite) SetupSuite() {
 func (suite *Iteration9Suite) serverUp(ctx context.Context, envs []string, port string) {
 	p := fork.NewBackgroundProcess(context.Background(), flagServerBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := p.Start(ctx)
@@ -75,6 +77,7 @@ func (suite *Iteration9Suite) serverUp(ctx context.Context, envs []string, port
 func (suite *Iteration9Suite) agentUp(ctx context.Context, envs []string, port string) {
 	suite.agentProcess = fork.NewBackgroundProcess(context.Background(), flagAgentBinaryPath,
 		fork.WithEnv(envs...),
+		instrumented.WithCoverDir(),
 	)
 
 	err := suite.agentProcess.Start(ctx)
@@ -101,6 +104,7 @@ func (suite *Iteration9Suite) serverShutdown() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -126,12 +130,46 @@ func (suite *Iteration9Suite) serverShutdown() {
 	}
 }
 
+// serverRestart перезапускает процесс сервера с прежними аргументами и переменными окружения
+func (suite *Iteration9Suite) serverRestart() {
+	suite.Require().NotNil(suite.serverProcess, "Процесс сервера не был запущен")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err := suite.serverProcess.Restart(ctx)
+	if err != nil {
+		suite.T().Errorf("Не удалось перезапустить процесс командой %q: %s", suite.serverProcess, err)
+		return
+	}
+
+	// выводим логи предыдущего запуска
+	incarnations := suite.serverProcess.Incarnations()
+	prev := incarnations[len(incarnations)-1]
+	if prev.ExitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", prev.ExitCode)
+	}
+	if len(prev.Stderr) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(prev.Stderr))
+	}
+	if len(prev.Stdout) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(prev.Stdout))
+	}
+
+	err = suite.serverProcess.WaitPort(ctx, "tcp", suite.serverPort)
+	if err != nil {
+		suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", suite.serverPort, err)
+		return
+	}
+}
+
 func (suite *Iteration9Suite) agentShutdown() {
 	if suite.agentProcess == nil {
 		return
 	}
 
 	exitCode, err := suite.agentProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.agentProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -261,10 +299,7 @@ func (suite *Iteration9Suite) TestCounterHandlers() {
 
 	suite.Run("restart server", func() {
 		time.Sleep(5 * time.Second) // relax time
-		suite.serverShutdown()
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		suite.serverUp(ctx, suite.envs, suite.serverPort)
+		suite.serverRestart()
 	})
 
 	suite.Run("get", func() {
@@ -371,10 +406,7 @@ func (suite *Iteration9Suite) TestGaugeHandlers() {
 
 	suite.Run("restart server", func() {
 		time.Sleep(5 * time.Second) // relax time
-		suite.serverShutdown()
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		suite.serverUp(ctx, suite.envs, suite.serverPort)
+		suite.serverRestart()
 	})
 
 	suite.Run("get", func() {
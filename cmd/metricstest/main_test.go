@@ -3,14 +3,35 @@ package main
 //go:generate go test -c -o=../../bin/metricstest
 
 import (
+	"flag"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
+// instrumented содержит исполняемые файлы, собранные в режиме -race-build, и nil в остальных случаях
+var instrumented *fork.Instrumented
+
 func TestMain(m *testing.M) {
-	os.Exit(m.Run())
+	flag.Parse()
+
+	// инициализируем генераторы псевдослучайных значений, чтобы запуск можно было повторить
+	random.SetupSeed()
+
+	if !flagRaceBuild {
+		os.Exit(m.Run())
+	}
+
+	// собираем агента и сервер с race detector и сбором покрытия
+	os.Exit(fork.RunInstrumented(m, flagTargetSourcePath, flagCoverProfilePath, func(b *fork.Instrumented) {
+		flagServerBinaryPath = b.Binary(flagServerBuildPackage)
+		flagAgentBinaryPath = b.Binary(flagAgentBuildPackage)
+		instrumented = b
+	}, flagServerBuildPackage, flagAgentBuildPackage))
 }
 
 func TestIteration1(t *testing.T) {
@@ -80,3 +101,15 @@ func TestIteration13(t *testing.T) {
 func TestIteration14(t *testing.T) {
 	suite.Run(t, new(Iteration14Suite))
 }
+
+func TestIteration15(t *testing.T) {
+	suite.Run(t, new(Iteration15Suite))
+}
+
+func TestIteration16(t *testing.T) {
+	suite.Run(t, new(Iteration16Suite))
+}
+
+func TestAgentIntervals(t *testing.T) {
+	suite.Run(t, new(AgentIntervalsSuite))
+}
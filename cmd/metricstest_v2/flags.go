@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 var (
@@ -26,4 +28,5 @@ func init() {
 	flag.StringVar(&flagFileStoragePath, "file-storage-path", "", "path to persistent file storage")
 	flag.StringVar(&flagDatabaseDSN, "database-dsn", "", "connection string to database")
 	flag.StringVar(&flagSHA256Key, "key", "", "sha256 key for hashing")
+	random.RegisterSeedFlag()
 }
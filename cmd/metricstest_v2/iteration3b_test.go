@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 type Iteration3BSuite struct {
@@ -23,11 +24,15 @@ type Iteration3BSuite struct {
 
 	serverAddress string
 	serverProcess *fork.BackgroundProcess
+
+	rnd *rand.Rand
 }
 
 func (suite *Iteration3BSuite) SetupSuite() {
 	suite.Require().NotEmpty(flagServerBinaryPath, "-binary-path non-empty flag required")
 
+	suite.rnd = random.NewRand(suite.T().Name())
+
 	suite.serverAddress = "http://localhost:8080"
 
 	// Для обеспечения обратной совместимости с будущими заданиями
@@ -95,10 +100,10 @@ func (suite *Iteration3BSuite) TestGauge() {
 
 	count := 3
 	suite.Run("update sequence", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		for i := 0; i < count; i++ {
-			v := strings.TrimRight(fmt.Sprintf("%.3f", rand.Float64()*1000000), "0.")
+			v := strings.TrimRight(fmt.Sprintf("%.3f", suite.rnd.Float64()*1000000), "0.")
 			resp, err := req.Post("update/gauge/testSetGet" + id + "/" + v)
 			noRespErr := suite.Assert().NoError(err,
 				"Ошибка при попытке сделать запрос с обновлением gauge")
@@ -127,7 +132,7 @@ func (suite *Iteration3BSuite) TestGauge() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/gauge/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err,
@@ -152,7 +157,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 	count := 3
 	suite.Run("update sequence", func() {
 		req := httpc.R()
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		resp, err := req.Get("value/counter/testSetGet" + id)
 		noRespErr := suite.Assert().NoError(err,
 			"Ошибка при попытке сделать запрос для получения значения counter")
@@ -166,7 +171,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 		a, _ := strconv.ParseInt(resp.String(), 0, 64)
 
 		for i := 0; i < count; i++ {
-			v := rand.Intn(1024)
+			v := suite.rnd.Intn(1024)
 			a += int64(v)
 			resp, err = req.Post("update/counter/testSetGet" + id + "/" + strconv.Itoa(v))
 
@@ -197,7 +202,7 @@ func (suite *Iteration3BSuite) TestCounter() {
 	})
 
 	suite.Run("get unknown", func() {
-		id := strconv.Itoa(rand.Intn(256))
+		id := strconv.Itoa(suite.rnd.Intn(256))
 		req := httpc.R()
 		resp, err := req.Get("value/counter/testUnknown" + id)
 		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для получения значения counter")
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+var digitsFlags = flag.NewFlagSet("digits", flag.ExitOnError)
+
+var (
+	flagDigitsMinLen = digitsFlags.Int("min-len", 5, "minimum length of digit string")
+	flagDigitsMaxLen = digitsFlags.Int("max-len", 15, "maximum length of digit string (exclusive)")
+	flagDigitsOutput = newOutputFlags(digitsFlags)
+)
+
+var digitsCmd = cmd{
+	name:      "digits",
+	shortHelp: "generates random digit strings",
+	do:        generateDigits,
+	flags:     digitsFlags,
+}
+
+func generateDigits() {
+	checkLenRange(*flagDigitsMinLen, *flagDigitsMaxLen)
+	flagDigitsOutput.print(func() any {
+		return random.DigitString(*flagDigitsMinLen, *flagDigitsMaxLen)
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+var luhnFlags = flag.NewFlagSet("luhn", flag.ExitOnError)
+
+var (
+	flagLuhnMinLen = luhnFlags.Int("min-len", 6, "minimum length of number including check digit")
+	flagLuhnMaxLen = luhnFlags.Int("max-len", 16, "maximum length of number including check digit (exclusive)")
+	flagLuhnKind   = luhnFlags.String("kind", "valid", "kind of number: valid, wrong-check-digit, transposed, non-digit or empty")
+	flagLuhnOutput = newOutputFlags(luhnFlags)
+)
+
+var luhnCmd = cmd{
+	name:      "luhn",
+	shortHelp: "generates order numbers valid or invalid by Luhn algorithm",
+	do:        generateLuhn,
+	flags:     luhnFlags,
+}
+
+func generateLuhn() {
+	checkLenRange(*flagLuhnMinLen, *flagLuhnMaxLen)
+
+	var gen func(minLen, maxLen int) string
+	switch *flagLuhnKind {
+	case "valid":
+		gen = random.LuhnNumber
+	case "wrong-check-digit":
+		gen = random.LuhnWrongCheckDigit
+	case "transposed":
+		gen = random.LuhnTransposed
+	case "non-digit":
+		gen = random.LuhnNonDigit
+	case "empty":
+		gen = func(int, int) string { return "" }
+	default:
+		fatalf("unknown kind %q", *flagLuhnKind)
+	}
+
+	flagLuhnOutput.print(func() any {
+		return gen(*flagLuhnMinLen, *flagLuhnMaxLen)
+	})
+}
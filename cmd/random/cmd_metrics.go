@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+var metricsFlags = flag.NewFlagSet("metrics", flag.ExitOnError)
+
+var (
+	flagMetricsType      = metricsFlags.String("type", "", "metric type: gauge or counter, random by default")
+	flagMetricsEdgeCases = metricsFlags.Bool("edge-cases", false, "print numeric edge cases with expected values, ignores -n")
+	flagMetricsOutput    = newOutputFlags(metricsFlags)
+)
+
+var metricsCmd = cmd{
+	name:      "metrics",
+	shortHelp: "generates metric payloads, text format prints update paths",
+	do:        generateMetrics,
+	flags:     metricsFlags,
+}
+
+// metricPayload is a metric printed as JSON or as text update path
+type metricPayload random.Metrics
+
+func (m metricPayload) text() string {
+	c := random.MetricCase{Updates: []random.Metrics{random.Metrics(m)}}
+	return c.UpdatePaths()[0]
+}
+
+// metricCase is an edge case printed as JSON or as text update paths
+type metricCase struct {
+	Class    string           `json:"class"`
+	Updates  []random.Metrics `json:"updates"`
+	Expected string           `json:"expected,omitempty"`
+	Overflow bool             `json:"overflow,omitempty"`
+	Extreme  bool             `json:"extreme,omitempty"`
+}
+
+func (c metricCase) text() string {
+	mc := random.MetricCase{Updates: c.Updates}
+	return strings.Join(mc.UpdatePaths(), "\n")
+}
+
+func generateMetrics() {
+	switch *flagMetricsType {
+	case "", random.Gauge, random.Counter:
+	default:
+		fatalf("unknown metric type %q", *flagMetricsType)
+	}
+
+	if *flagMetricsEdgeCases {
+		var cases []random.MetricCase
+		switch *flagMetricsType {
+		case random.Gauge:
+			cases = random.GaugeCases()
+		case random.Counter:
+			cases = random.CounterCases()
+		default:
+			cases = random.MetricCases()
+		}
+
+		values := make([]any, 0, len(cases))
+		for _, c := range cases {
+			values = append(values, metricCase{
+				Class:    c.Class,
+				Updates:  c.Updates,
+				Expected: c.Expected,
+				Overflow: c.Overflow,
+				Extreme:  c.Extreme,
+			})
+		}
+		flagMetricsOutput.printValues(values)
+		return
+	}
+
+	flagMetricsOutput.print(func() any {
+		return metricPayload(random.Metric(*flagMetricsType))
+	})
+}
@@ -1,21 +1,107 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
+var unusedPortFlags = flag.NewFlagSet("unused-port", flag.ExitOnError)
+
+var (
+	flagPortLease        = unusedPortFlags.Bool("lease", false, "keep the port locked for other autotest binaries in background until it is bound and released by the target or timeout expires")
+	flagPortLeaseTimeout = unusedPortFlags.Duration("lease-timeout", 10*time.Minute, "maximum duration of port lease")
+	// flagPortLeaseHold is used internally by background process holding the lease
+	flagPortLeaseHold = unusedPortFlags.Bool("lease-hold", false, "hold the lease in current process, used internally by -lease")
+)
+
 var unusedPortCmd = cmd{
 	name:      "unused-port",
 	shortHelp: "finds and returns random unused port number",
 	do:        generateUnusedPort,
+	flags:     unusedPortFlags,
 }
 
 func generateUnusedPort() {
-	port, err := random.UnusedPort()
+	switch {
+	case *flagPortLeaseHold:
+		holdPortLease()
+		return
+	case *flagPortLease:
+		leasePortInBackground()
+		return
+	}
+
+	lease, err := random.LeasePort()
 	if err != nil {
 		fatalf("cannot find unused port: %s", err)
 	}
+	_ = lease.Release()
+	fmt.Print(lease.Port())
+}
+
+// leasePortInBackground starts background process holding the lease and prints leased port.
+// Background process is needed as the lease must outlive the command, e.g. in PORT=$(random unused-port -lease)
+func leasePortInBackground() {
+	cmd := exec.Command(os.Args[0], "unused-port", "-lease-hold", "-lease-timeout", flagPortLeaseTimeout.String())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fatalf("cannot create pipe: %s", err)
+	}
+	if err := cmd.Start(); err != nil {
+		fatalf("cannot start background process: %s", err)
+	}
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	port := strings.TrimSpace(line)
+	if port == "" {
+		_ = cmd.Process.Kill()
+		fatalf("cannot lease port: %v", err)
+	}
+
+	// background process keeps running after we exit
+	_ = cmd.Process.Release()
 	fmt.Print(port)
 }
+
+// holdPortLease leases port, reports it to stdout and holds the lock
+// until the port is bound and released by the target or timeout expires
+func holdPortLease() {
+	lease, err := random.LeasePort()
+	if err != nil {
+		fatalf("cannot lease port: %s", err)
+	}
+	defer lease.Release()
+
+	if err := lease.Handoff(); err != nil {
+		fatalf("cannot hand off port: %s", err)
+	}
+	fmt.Println(lease.Port())
+	// closing stdout lets shell command substitution finish while we keep running
+	_ = os.Stdout.Close()
+
+	deadline := time.Now().Add(*flagPortLeaseTimeout)
+	bound := false
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", lease.Addr(), 100*time.Millisecond)
+		if conn != nil {
+			_ = conn.Close()
+		}
+
+		switch {
+		case err == nil:
+			bound = true
+		case bound:
+			// target has released the port
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+var stringFlags = flag.NewFlagSet("string", flag.ExitOnError)
+
+var (
+	flagStringMinLen = stringFlags.Int("min-len", 5, "minimum length of string")
+	flagStringMaxLen = stringFlags.Int("max-len", 15, "maximum length of string (exclusive)")
+	flagStringOutput = newOutputFlags(stringFlags)
+)
+
+var stringCmd = cmd{
+	name:      "string",
+	shortHelp: "generates random ASCII strings",
+	do:        generateString,
+	flags:     stringFlags,
+}
+
+func generateString() {
+	checkLenRange(*flagStringMinLen, *flagStringMaxLen)
+	flagStringOutput.print(func() any {
+		return random.ASCIIString(*flagStringMinLen, *flagStringMaxLen)
+	})
+}
+
+// checkLenRange stops the program if length range is empty
+func checkLenRange(minLen, maxLen int) {
+	if minLen < 0 || maxLen <= minLen {
+		fatalf("-max-len must be greater than -min-len, got %d and %d", maxLen, minLen)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+var urlFlags = flag.NewFlagSet("url", flag.ExitOnError)
+
+var (
+	flagURLScheme    = urlFlags.String("scheme", "http", "URL scheme")
+	flagURLQuery     = urlFlags.Bool("query", false, "add query string")
+	flagURLFragment  = urlFlags.Bool("fragment", false, "add fragment")
+	flagURLPort      = urlFlags.Bool("port", false, "add explicit port")
+	flagURLUserinfo  = urlFlags.Bool("userinfo", false, "add user name and password")
+	flagURLPercent   = urlFlags.Bool("percent-encoding", false, "add percent-encoded bytes")
+	flagURLIDN       = urlFlags.Bool("idn", false, "use internationalized domain name in punycode form")
+	flagURLMinLength = urlFlags.Int("min-length", 0, "minimal length of URL")
+	flagURLAll       = urlFlags.Bool("all", false, "enable all URL features")
+	flagURLEdgeCases = urlFlags.Bool("edge-cases", false, "print URLs exercising each feature separately and all at once, ignores -n")
+	flagURLInvalid   = urlFlags.Bool("invalid", false, "print corpus of invalid URLs, ignores -n")
+	flagURLUnusable  = urlFlags.Bool("unusable", false, "print corpus of parseable but unusable URLs, ignores -n")
+	flagURLOutput    = newOutputFlags(urlFlags)
+)
+
+var urlCmd = cmd{
+	name:      "url",
+	shortHelp: "generates random valid URLs or prints invalid URL corpus",
+	do:        generateURL,
+	flags:     urlFlags,
+}
+
+func generateURL() {
+	switch {
+	case *flagURLInvalid:
+		var values []any
+		for _, s := range random.InvalidURLs() {
+			values = append(values, s)
+		}
+		flagURLOutput.printValues(values)
+		return
+	case *flagURLUnusable:
+		var values []any
+		for _, s := range random.UnusableURLs() {
+			values = append(values, s)
+		}
+		flagURLOutput.printValues(values)
+		return
+	case *flagURLEdgeCases:
+		var values []any
+		for _, u := range random.EdgeCaseURLs() {
+			values = append(values, u.String())
+		}
+		flagURLOutput.printValues(values)
+		return
+	}
+
+	opts := []random.URLOption{random.WithScheme(*flagURLScheme)}
+	if *flagURLAll {
+		opts = append(opts, random.AllURLOptions()...)
+	}
+	if *flagURLQuery {
+		opts = append(opts, random.WithQuery())
+	}
+	if *flagURLFragment {
+		opts = append(opts, random.WithFragment())
+	}
+	if *flagURLPort {
+		opts = append(opts, random.WithPort())
+	}
+	if *flagURLUserinfo {
+		opts = append(opts, random.WithUserinfo())
+	}
+	if *flagURLPercent {
+		opts = append(opts, random.WithPercentEncoding())
+	}
+	if *flagURLIDN {
+		opts = append(opts, random.WithIDN())
+	}
+	if *flagURLMinLength > 0 {
+		opts = append(opts, random.WithMinLength(*flagURLMinLength))
+	}
+
+	flagURLOutput.print(func() any {
+		return random.NewURL(opts...).String()
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/gofrs/uuid"
+)
+
+var uuidFlags = flag.NewFlagSet("uuid", flag.ExitOnError)
+
+var flagUUIDOutput = newOutputFlags(uuidFlags)
+
+var uuidCmd = cmd{
+	name:      "uuid",
+	shortHelp: "generates random UUID v4",
+	do:        generateUUID,
+	flags:     uuidFlags,
+}
+
+func generateUUID() {
+	flagUUIDOutput.print(func() any {
+		id, err := uuid.NewV4()
+		if err != nil {
+			fatalf("cannot generate UUID: %s", err)
+		}
+		return id.String()
+	})
+}
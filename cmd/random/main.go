@@ -12,6 +12,12 @@ var cmds = []cmd{
 	unusedPortCmd,
 	domainCmd,
 	tempfileCmd,
+	stringCmd,
+	digitsCmd,
+	urlCmd,
+	luhnCmd,
+	uuidCmd,
+	metricsCmd,
 }
 
 type cmd struct {
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// outputFlags holds common flags of data-producing commands
+type outputFlags struct {
+	n      *int
+	format *string
+}
+
+// newOutputFlags registers -n and -format flags in fs
+func newOutputFlags(fs *flag.FlagSet) outputFlags {
+	return outputFlags{
+		n:      fs.Int("n", 1, "number of values to generate"),
+		format: fs.String("format", "text", "output format: text, json or ndjson"),
+	}
+}
+
+// textMarshaler is implemented by values having special text representation
+type textMarshaler interface {
+	text() string
+}
+
+// print generates values with gen and prints them in requested format.
+// Text format prints one value per line without trailing newline, json prints an array,
+// ndjson prints one JSON value per line
+func (o outputFlags) print(gen func() any) {
+	if *o.n < 0 {
+		fatalf("-n must not be negative, got %d", *o.n)
+	}
+
+	values := make([]any, 0, *o.n)
+	for i := 0; i < *o.n; i++ {
+		values = append(values, gen())
+	}
+	o.printValues(values)
+}
+
+// printValues prints already generated values in requested format
+func (o outputFlags) printValues(values []any) {
+	switch *o.format {
+	case "text":
+		lines := make([]string, 0, len(values))
+		for _, v := range values {
+			if t, ok := v.(textMarshaler); ok {
+				lines = append(lines, t.text())
+				continue
+			}
+			lines = append(lines, fmt.Sprint(v))
+		}
+		fmt.Print(strings.Join(lines, "\n"))
+	case "json":
+		if values == nil {
+			values = []any{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(values); err != nil {
+			fatalf("cannot encode values: %s", err)
+		}
+	case "ndjson":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, v := range values {
+			if err := enc.Encode(v); err != nil {
+				fatalf("cannot encode value: %s", err)
+			}
+		}
+	default:
+		fatalf("unknown output format %q, expected text, json or ndjson", *o.format)
+	}
+}
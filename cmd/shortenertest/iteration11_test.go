@@ -124,14 +124,7 @@ func (suite *Iteration11Suite) TestInspectDatabase() {
 
 	// инспектируем БД после рестарта приложения
 	suite.Run("check_after_restart", func() {
-		out, err := suite.stopServer()
-		noRestartErr := suite.Assert().NoError(err, "Не удалось остановить процесс сервера")
-
-		if !noRestartErr && len(out) > 0 {
-			suite.T().Log(string(out))
-		}
-
-		err = suite.startServer()
+		err := suite.restartServer()
 		suite.Require().NoError(err, "Не удалось перезапустить процесс сервера")
 
 		suite.inspectTables(originalURL)
@@ -144,6 +137,7 @@ func (suite *Iteration11Suite) startServer() error {
 	p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -165,9 +159,33 @@ func (suite *Iteration11Suite) startServer() error {
 	return nil
 }
 
+// restartServer перезапускает процесс сервера с прежними аргументами и переменными окружения
+func (suite *Iteration11Suite) restartServer() error {
+	if suite.serverProcess == nil {
+		return errors.New("Процесс сервера не был запущен")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	err := suite.serverProcess.Restart(ctx)
+	if err != nil {
+		return fmt.Errorf("Невозможно перезапустить процесс командой %s: %w", suite.serverProcess, err)
+	}
+
+	// ожидаем пока порт будет занят
+	port := "8080"
+	err = suite.serverProcess.WaitPort(ctx, "tcp", port)
+	if err != nil {
+		return fmt.Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %w", port, err)
+	}
+	return nil
+}
+
 // stopServer останавливает процесс сервера
 func (suite *Iteration11Suite) stopServer() (log []byte, err error) {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return nil, nil
@@ -44,6 +44,7 @@ func (suite *Iteration12Suite) SetupSuite() {
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -98,6 +99,7 @@ func (suite *Iteration12Suite) TearDownSuite() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -53,6 +53,7 @@ func (suite *Iteration14Suite) SetupSuite() {
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -106,6 +107,7 @@ func (suite *Iteration14Suite) TearDownSuite() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
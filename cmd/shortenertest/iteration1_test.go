@@ -36,6 +36,7 @@ func (suite *Iteration1Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -49,11 +50,14 @@ func (suite *Iteration1Suite) SetupSuite() {
 			return
 		}
 
-		// проверяем, что порт успешно занят процессом
+		// проверяем, что порт успешно занят процессом и сервер отвечает на HTTP запросы
 		port := "8080"
-		err = p.WaitPort(ctx, "tcp", port)
+		err = p.WaitReady(ctx,
+			fork.PortProbe("tcp", port),
+			fork.HTTPProbe(suite.serverAddress),
+		)
 		if err != nil {
-			suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
+			suite.T().Errorf("Не удалось дождаться пока сервер на порту %s станет доступен для запроса: %s", port, err)
 			return
 		}
 	}
@@ -63,6 +67,7 @@ func (suite *Iteration1Suite) SetupSuite() {
 func (suite *Iteration1Suite) TearDownSuite() {
 	// посылаем процессу сигналы для остановки
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -45,6 +45,7 @@ func (suite *Iteration4Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -73,6 +74,7 @@ func (suite *Iteration4Suite) SetupSuite() {
 func (suite *Iteration4Suite) TearDownSuite() {
 	// посылаем процессу сигналы для остановки
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -41,6 +41,7 @@ func (suite *Iteration5Suite) SetupSuite() {
 
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -67,6 +68,7 @@ func (suite *Iteration5Suite) SetupSuite() {
 func (suite *Iteration5Suite) TearDownSuite() {
 	// останавливаем процесс
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -46,6 +46,7 @@ func (suite *Iteration6Suite) SetupSuite() {
 
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -165,6 +166,7 @@ func (suite *Iteration6Suite) TestPersistentFile() {
 // stopServer останавливает процесс сервера
 func (suite *Iteration6Suite) stopServer() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
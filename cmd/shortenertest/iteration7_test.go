@@ -57,6 +57,7 @@ func (suite *Iteration7Suite) SetupSuite() {
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -235,6 +236,7 @@ func (suite *Iteration7Suite) TestFlags() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration7Suite) stopServer() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
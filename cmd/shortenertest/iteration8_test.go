@@ -37,6 +37,7 @@ func (suite *Iteration8Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -61,6 +62,7 @@ func (suite *Iteration8Suite) SetupSuite() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration8Suite) TearDownSuite() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
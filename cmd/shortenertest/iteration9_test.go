@@ -36,6 +36,7 @@ func (suite *Iteration9Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -60,6 +61,7 @@ func (suite *Iteration9Suite) SetupSuite() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration9Suite) TearDownSuite() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
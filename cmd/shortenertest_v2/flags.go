@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Доступные для тест-сьютов флаги командной строки
@@ -16,6 +18,9 @@ var (
 	flagBaseProfilePath   string
 	flagResultProfilePath string
 	flagPackageName       string
+	flagRaceBuild         bool   // собирать проект из исходного кода с -race и -cover
+	flagBuildPackage      string // пакет проекта, из которого собирается сервер
+	flagCoverProfilePath  string // путь до итогового профиля интеграционного покрытия
 )
 
 func init() {
@@ -29,4 +34,8 @@ func init() {
 	flag.StringVar(&flagBaseProfilePath, "base-profile-path", "", "path to base pprof profile")
 	flag.StringVar(&flagResultProfilePath, "result-profile-path", "", "path to result pprof profile")
 	flag.StringVar(&flagPackageName, "package-name", "", "name of package to be tested")
+	flag.BoolVar(&flagRaceBuild, "race-build", false, "build target HTTP server from -source-path with -race and -cover instead of using -binary-path")
+	flag.StringVar(&flagBuildPackage, "build-package", "./cmd/shortener", "package of target HTTP server to build in -race-build mode")
+	flag.StringVar(&flagCoverProfilePath, "coverprofile", "", "path to write integration coverage profile to in -race-build mode")
+	random.RegisterSeedFlag()
 }
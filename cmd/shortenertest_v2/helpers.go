@@ -16,6 +16,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
 	"golang.org/x/tools/go/ast/astutil"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/random"
@@ -27,6 +29,59 @@ func generateTestURL(t *testing.T) string {
 	return random.URL().String()
 }
 
+// generateEdgeCaseURLs возвращает набор валидных URL с граничными случаями:
+// query-параметрами, фрагментом, портом, userinfo, percent-encoding, IDN доменом и очень длинным путем
+func generateEdgeCaseURLs(t *testing.T) []string {
+	t.Helper()
+
+	var res []string
+	for _, u := range random.EdgeCaseURLs() {
+		res = append(res, u.String())
+	}
+	return res
+}
+
+// assertLocationRoundTrip проверяет, что хендлер редиректа по сокращенному URL shortenURL
+// возвращает в заголовке Location оригинальный URL originalURL побайтно без изменений
+func assertLocationRoundTrip(t *testing.T, shortenURL, originalURL string) bool {
+	t.Helper()
+
+	// создаем HTTP клиент без поддержки редиректов
+	errRedirectBlocked := errors.New("HTTP redirect blocked")
+	redirPolicy := resty.RedirectPolicyFunc(func(_ *http.Request, _ []*http.Request) error {
+		return errRedirectBlocked
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := resty.New().
+		SetRedirectPolicy(redirPolicy).
+		R()
+	resp, err := req.
+		SetContext(ctx).
+		Get(shortenURL)
+
+	noRespErr := true
+	if !errors.Is(err, errRedirectBlocked) {
+		noRespErr = assert.NoErrorf(t, err, "Ошибка при попытке сделать запрос для получения исходного URL")
+	}
+
+	validStatus := assert.Equalf(t, http.StatusTemporaryRedirect, resp.StatusCode(),
+		"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL,
+	)
+	validURL := assert.Equalf(t, originalURL, resp.Header().Get("Location"),
+		"Несоответствие URL полученного в заголовке Location ожидаемому: URL должен возвращаться без каких-либо изменений",
+	)
+
+	if !noRespErr || !validStatus || !validURL {
+		dump := dumpRequest(req.RawRequest, true)
+		t.Logf("Оригинальный запрос:\n\n%s", dump)
+		return false
+	}
+	return true
+}
+
 // usesKnownPackage проверяет, что хотя бы в одном файле, начиная с указанной директории rootdir,
 // содержится хотя бы один пакет из списка knownPackages
 func usesKnownPackage(t *testing.T, rootdir string, knownPackages []string) error {
@@ -45,6 +45,7 @@ func (suite *Iteration10Suite) SetupSuite() {
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -69,6 +70,7 @@ func (suite *Iteration10Suite) SetupSuite() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration10Suite) TearDownSuite() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
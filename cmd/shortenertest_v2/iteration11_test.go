@@ -145,6 +145,7 @@ func (suite *Iteration11Suite) startServer() error {
 	p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 		fork.WithEnv(envs...),
 		fork.WithArgs(args...),
+		instrumented.WithCoverDir(),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
@@ -169,6 +170,7 @@ func (suite *Iteration11Suite) startServer() error {
 // stopServer останавливает процесс сервера
 func (suite *Iteration11Suite) stopServer() (log []byte, err error) {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return nil, nil
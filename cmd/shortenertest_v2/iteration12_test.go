@@ -44,6 +44,7 @@ func (suite *Iteration12Suite) SetupSuite() {
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -98,6 +99,7 @@ func (suite *Iteration12Suite) TearDownSuite() {
 	}
 
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -246,3 +248,67 @@ func (suite *Iteration12Suite) TestBatchShorten() {
 		}
 	})
 }
+
+// TestBatchShortenEdgeCaseURLs посылает пачку URL с граничными случаями на сокращение
+// и проверяет, что хендлер редиректа возвращает их побайтно без изменений
+func (suite *Iteration12Suite) TestBatchShortenEdgeCaseURLs() {
+	// модель запроса
+	type shortenRequest struct {
+		CorrelationID string `json:"correlation_id"`
+		OriginalURL   string `json:"original_url"`
+	}
+
+	// модель ответа
+	type shortenResponse struct {
+		CorrelationID string `json:"correlation_id"`
+		ShortURL      string `json:"short_url"`
+	}
+
+	var requestData []shortenRequest
+	for _, originalURL := range generateEdgeCaseURLs(suite.T()) {
+		requestData = append(requestData, shortenRequest{
+			CorrelationID: uuid.Must(uuid.NewV4()).String(),
+			OriginalURL:   originalURL,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var responseData []shortenResponse
+	req := resty.New().
+		SetBaseURL(suite.serverAddress).
+		R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(requestData).
+		SetResult(&responseData)
+	resp, err := req.Post("/api/shorten/batch")
+
+	noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для множественного сокращения URL")
+	validStatus := suite.Assert().Equalf(http.StatusCreated, resp.StatusCode(),
+		"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+	validLen := suite.Assert().Len(responseData, len(requestData), "Кол-во объектов в ответе не совпадает с кол-вом объектов в запросе")
+
+	if !noRespErr || !validStatus || !validLen {
+		dump := dumpRequest(req.RawRequest, true)
+		jsonBody, _ := json.Marshal(requestData)
+		suite.T().Logf("Оригинальный запрос:\n\n%s\n\nТело запроса:\n\n%s", dump, jsonBody)
+		return
+	}
+
+	for _, respPair := range responseData {
+		var originalURL string
+		for _, reqPair := range requestData {
+			if respPair.CorrelationID == reqPair.CorrelationID {
+				originalURL = reqPair.OriginalURL
+				break
+			}
+		}
+
+		if !suite.Assert().NotEmptyf(originalURL, "Не удалось найти оригинальный URL по correlation ID: %s", respPair.CorrelationID) {
+			continue
+		}
+		assertLocationRoundTrip(suite.T(), respPair.ShortURL, originalURL)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -13,6 +14,8 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/prop"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Iteration1Suite является сьютом с тестами и состоянием для инкремента
@@ -36,6 +39,7 @@ func (suite *Iteration1Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -63,6 +67,7 @@ func (suite *Iteration1Suite) SetupSuite() {
 func (suite *Iteration1Suite) TearDownSuite() {
 	// посылаем процессу сигналы для остановки
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -174,3 +179,110 @@ func (suite *Iteration1Suite) TestHandlers() {
 		}
 	})
 }
+
+// TestEdgeCaseURLs посылает на сокращение URL с граничными случаями
+// и проверяет, что хендлер редиректа возвращает их побайтно без изменений
+func (suite *Iteration1Suite) TestEdgeCaseURLs() {
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress)
+
+	for i, originalURL := range generateEdgeCaseURLs(suite.T()) {
+		suite.Run(fmt.Sprintf("url_%d", i), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// делаем запрос к серверу для сокращения URL
+			req := httpc.R().
+				SetContext(ctx).
+				SetHeader("Content-Type", "text/plain").
+				SetBody(originalURL)
+			resp, err := req.Post("/")
+
+			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+			validStatus := suite.Assert().Equalf(http.StatusCreated, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+
+			if !noRespErr || !validStatus {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				return
+			}
+
+			assertLocationRoundTrip(suite.T(), string(resp.Body()), originalURL)
+		})
+	}
+}
+
+// и проверяет, что хендлер отвечает на них статусом 400
+// и проверяет, что хендлер отклоняет их со статусом 400
+func (suite *Iteration1Suite) TestInvalidURLs() {
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress)
+
+	for i, invalidURL := range random.InvalidURLs() {
+		suite.Run(fmt.Sprintf("url_%d", i), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			req := httpc.R().
+				SetContext(ctx).
+				SetHeader("Content-Type", "text/plain").
+				SetBody(invalidURL)
+			resp, err := req.Post("/")
+
+			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+			validStatus := noRespErr && suite.Assert().Equalf(http.StatusBadRequest, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s' для некорректного URL %q", req.Method, req.URL, invalidURL)
+
+			if !noRespErr || !validStatus {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			}
+		})
+	}
+}
+
+// TestShortenProperty проверяет свойство: для любого URL сокращение и последующий редирект
+// возвращают исходный URL. При нарушении выводится минимальный найденный URL
+func (suite *Iteration1Suite) TestShortenProperty() {
+	// создаем HTTP клиент без поддержки редиректов
+	errRedirectBlocked := errors.New("HTTP redirect blocked")
+	redirPolicy := resty.RedirectPolicyFunc(func(_ *http.Request, _ []*http.Request) error {
+		return errRedirectBlocked
+	})
+
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress).
+		SetRedirectPolicy(redirPolicy)
+
+	prop.Check(suite.T(), prop.URL(), func(originalURL string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		resp, err := httpc.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "text/plain").
+			SetBody(originalURL).
+			Post("/")
+		if err != nil {
+			return fmt.Errorf("ошибка при попытке сделать запрос для сокращения URL: %w", err)
+		}
+		if resp.StatusCode() != http.StatusCreated {
+			return fmt.Errorf("хендлер сокращения вернул статус %d вместо %d", resp.StatusCode(), http.StatusCreated)
+		}
+
+		resp, err = httpc.R().
+			SetContext(ctx).
+			Get(string(resp.Body()))
+		if err != nil && !errors.Is(err, errRedirectBlocked) {
+			return fmt.Errorf("ошибка при попытке сделать запрос для получения исходного URL: %w", err)
+		}
+		if resp.StatusCode() != http.StatusTemporaryRedirect {
+			return fmt.Errorf("хендлер редиректа вернул статус %d вместо %d", resp.StatusCode(), http.StatusTemporaryRedirect)
+		}
+		if location := resp.Header().Get("Location"); location != originalURL {
+			return fmt.Errorf("в заголовке Location получен URL %q", location)
+		}
+		return nil
+	}, prop.WithRuns(20))
+}
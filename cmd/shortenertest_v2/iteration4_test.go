@@ -44,6 +44,7 @@ func (suite *Iteration4Suite) SetupSuite() {
 
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithArgs(args...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -154,6 +155,7 @@ func (suite *Iteration4Suite) TestFlags() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration4Suite) stopServer() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
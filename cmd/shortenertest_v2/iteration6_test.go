@@ -1,22 +1,38 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/logscan"
 )
 
-// Iteration9Suite является сьютом с тестами и состоянием для инкремента
+// Iteration6Suite является сьютом с тестами и состоянием для инкремента
 type Iteration6Suite struct {
 	suite.Suite
 
 	knownLoggers []string
+
+	serverAddress string
+	serverProcess *fork.BackgroundProcess
+	serverLogs    *logscan.Collector
 }
 
 // SetupSuite подготавливает необходимые зависимости
 func (suite *Iteration6Suite) SetupSuite() {
 	// проверяем наличие необходимых флагов
 	suite.Require().NotEmpty(flagTargetSourcePath, "-source-path non-empty flag required")
+	suite.Require().NotEmpty(flagTargetBinaryPath, "-binary-path non-empty flag required")
 
 	// список известных логгеров
 	suite.knownLoggers = []string{
@@ -25,6 +41,79 @@ func (suite *Iteration6Suite) SetupSuite() {
 		"github.com/sirupsen/logrus",
 		"log/slog",
 	}
+
+	// запускаем процесс тестируемого сервера, собирая структурированные записи из его вывода
+	{
+		suite.serverLogs = logscan.NewCollector()
+		envs := append(os.Environ(), []string{
+			"SERVER_ADDRESS={{.Addrs.server}}",
+			"BASE_URL=http://{{.Addrs.server}}",
+		}...)
+
+		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
+			fork.WithEnv(envs...),
+			fork.WithAutoPort("server"),
+			fork.WithLineHandler(suite.serverLogs.Handle),
+			instrumented.WithCoverDir(),
+		)
+		suite.serverProcess = p
+
+		// ожидаем запуска процесса не более 20 секунд
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		// запускаем процесс
+		err := p.Start(ctx)
+		if err != nil {
+			suite.T().Errorf("Невозможно запустить процесс командой %s: %s. Переменные окружения: %+v", p, err, envs)
+			return
+		}
+
+		// проверяем, что порт успешно занят процессом
+		port := strconv.Itoa(p.Port("server"))
+		err = p.WaitReady(ctx, fork.PortProbe("tcp", port))
+		if err != nil {
+			suite.T().Errorf("Не удалось дождаться пока порт %s станет доступен для запроса: %s", port, err)
+			return
+		}
+		suite.serverAddress = "http://" + p.Addr("server")
+	}
+}
+
+// TearDownSuite высвобождает имеющиеся зависимости
+func (suite *Iteration6Suite) TearDownSuite() {
+	if suite.serverProcess == nil {
+		return
+	}
+
+	// посылаем процессу сигналы для остановки
+	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
+	if err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return
+		}
+		suite.T().Logf("Не удалось остановить процесс с помощью сигнала ОС: %s", err)
+		return
+	}
+
+	// проверяем код завешения
+	if exitCode > 0 {
+		suite.T().Logf("Процесс завершился с не нулевым статусом %d", exitCode)
+	}
+
+	// получаем стандартные выводы (логи) процесса
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	out := suite.serverProcess.Stderr(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDERR лог процесса:\n\n%s", string(out))
+	}
+	out = suite.serverProcess.Stdout(ctx)
+	if len(out) > 0 {
+		suite.T().Logf("Получен STDOUT лог процесса:\n\n%s", string(out))
+	}
 }
 
 // TestLoggerUsage пробует рекурсивно найти хотя бы одно использование известных логгеров в директории с исходным кодом проекта
@@ -40,3 +129,107 @@ func (suite *Iteration6Suite) TestLoggerUsage() {
 	}
 	suite.T().Errorf("Неожиданная ошибка при поиске использования логгера по пути %s: %s", flagTargetSourcePath, err)
 }
+
+// TestRequestLogging проверяет, что сервер записывает в лог сведения о каждом запросе и ответе на него
+func (suite *Iteration6Suite) TestRequestLogging() {
+	originalURL := generateTestURL(suite.T())
+	var shortenPath string
+
+	// создаем HTTP клиент без поддержки редиректов
+	errRedirectBlocked := errors.New("HTTP redirect blocked")
+	redirPolicy := resty.RedirectPolicyFunc(func(_ *http.Request, _ []*http.Request) error {
+		return errRedirectBlocked
+	})
+
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress).
+		SetRedirectPolicy(redirPolicy)
+
+	suite.Run("shorten", func() {
+		// весь тест должен проходить менее чем за 10 секунд
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		since := time.Now()
+		req := httpc.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "text/plain").
+			SetBody(originalURL)
+		resp, err := req.Post("/")
+
+		noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+		validStatus := suite.Assert().Equalf(http.StatusCreated, resp.StatusCode(),
+			"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+
+		u, urlParseErr := url.Parse(string(resp.Body()))
+		validURL := suite.Assert().NoErrorf(urlParseErr,
+			"Невозможно распарсить полученный сокращенный URL - %s : %s", resp.Body(), urlParseErr,
+		)
+
+		if !noRespErr || !validStatus || !validURL {
+			dump := dumpRequest(req.RawRequest, true)
+			suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			return
+		}
+		shortenPath = u.RequestURI()
+
+		suite.checkRequestLogged(ctx, since, http.MethodPost, "/", resp, true)
+	})
+
+	suite.Run("expand", func() {
+		if shortenPath == "" {
+			suite.T().Skip("Сокращенный URL не был получен")
+		}
+
+		// весь тест должен проходить менее чем за 10 секунд
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		since := time.Now()
+		req := httpc.R().SetContext(ctx)
+		resp, err := req.Get(shortenPath)
+
+		noRespErr := true
+		if !errors.Is(err, errRedirectBlocked) {
+			noRespErr = suite.Assert().NoErrorf(err, "Ошибка при попытке сделать запрос для получения исходного URL")
+		}
+		validStatus := suite.Assert().Equalf(http.StatusTemporaryRedirect, resp.StatusCode(),
+			"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL,
+		)
+
+		if !noRespErr || !validStatus {
+			dump := dumpRequest(req.RawRequest, true)
+			suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			return
+		}
+
+		// тело ответа с редиректом не вычитывается клиентом, поэтому размер не сравнивается
+		suite.checkRequestLogged(ctx, since, http.MethodGet, shortenPath, resp, false)
+	})
+}
+
+// checkRequestLogged проверяет записи лога сервера, относящиеся к выполненному запросу
+func (suite *Iteration6Suite) checkRequestLogged(ctx context.Context, since time.Time, method, uri string, resp *resty.Response, compareSize bool) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rec, err := suite.serverLogs.WaitRequest(ctx, since, method, uri)
+	if err != nil {
+		suite.T().Errorf("Не удалось найти в логе сервера сведения о запросе %s %s: %s\n"+
+			"Сведения о запросе должны содержать URI, метод и время выполнения, сведения об ответе - код статуса и размер содержимого.\n"+
+			"Найденные записи:\n%s\nВсего строк вывода: %d, распознано структурированных записей: %d",
+			method, uri, err, rec, suite.serverLogs.Lines(), len(suite.serverLogs.Entries()))
+		return
+	}
+
+	status, ok := rec.Status()
+	suite.Assert().Truef(ok && status == resp.StatusCode(),
+		"Код статуса в логе (%q) не соответствует коду статуса ответа %d на запрос %s %s:\n%s",
+		rec.Fields[logscan.FieldStatus], resp.StatusCode(), method, uri, rec)
+
+	// размер сжатого ответа не совпадает с размером распакованного тела
+	if size, ok := rec.Size(); ok && compareSize && !resp.RawResponse.Uncompressed {
+		suite.Assert().Equalf(len(resp.Body()), size,
+			"Размер содержимого в логе не соответствует размеру ответа на запрос %s %s:\n%s", method, uri, rec)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/suite"
 )
@@ -46,6 +48,7 @@ func (suite *Iteration7Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -74,6 +77,7 @@ func (suite *Iteration7Suite) SetupSuite() {
 func (suite *Iteration7Suite) TearDownSuite() {
 	// посылаем процессу сигналы для остановки
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -212,3 +216,85 @@ func (suite *Iteration7Suite) TestJSONHandler() {
 		}
 	})
 }
+
+// TestJSONHandlerEdgeCaseURLs посылает в JSON хендлер на сокращение URL с граничными случаями
+// и проверяет, что хендлер редиректа возвращает их побайтно без изменений
+func (suite *Iteration7Suite) TestJSONHandlerEdgeCaseURLs() {
+	// структура тела запроса
+	type shortenRequest struct {
+		URL string `json:"url"`
+	}
+	// структура тела ответа
+	type shortenResponse struct {
+		Result string `json:"result"`
+	}
+
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress)
+
+	for i, originalURL := range generateEdgeCaseURLs(suite.T()) {
+		suite.Run(fmt.Sprintf("url_%d", i), func() {
+			var result shortenResponse
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			req := httpc.R().
+				SetContext(ctx).
+				SetHeader("Content-Type", "application/json").
+				SetBody(&shortenRequest{
+					URL: originalURL,
+				}).
+				SetResult(&result)
+			resp, err := req.Post("/api/shorten")
+
+			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+			validStatus := suite.Assert().Equalf(http.StatusCreated, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+
+			if !noRespErr || !validStatus {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				return
+			}
+
+			assertLocationRoundTrip(suite.T(), result.Result, originalURL)
+		})
+	}
+}
+
+// TestJSONHandlerInvalidURLs посылает в JSON хендлер на сокращение строки, которые отклоняет
+// url.ParseRequestURI, и проверяет, что хендлер отвечает на них статусом 400
+func (suite *Iteration7Suite) TestJSONHandlerInvalidURLs() {
+	// структура тела запроса
+	type shortenRequest struct {
+		URL string `json:"url"`
+	}
+
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress)
+
+	for i, invalidURL := range random.InvalidURLs() {
+		suite.Run(fmt.Sprintf("url_%d", i), func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			req := httpc.R().
+				SetContext(ctx).
+				SetHeader("Content-Type", "application/json").
+				SetBody(&shortenRequest{
+					URL: invalidURL,
+				})
+			resp, err := req.Post("/api/shorten")
+
+			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+			validStatus := noRespErr && suite.Assert().Equalf(http.StatusBadRequest, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s' для некорректного URL %q", req.Method, req.URL, invalidURL)
+
+			if !noRespErr || !validStatus {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+			}
+		})
+	}
+}
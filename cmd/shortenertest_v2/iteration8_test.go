@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -37,6 +38,7 @@ func (suite *Iteration8Suite) SetupSuite() {
 		envs := os.Environ()
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -61,6 +63,7 @@ func (suite *Iteration8Suite) SetupSuite() {
 // TearDownSuite высвобождает имеющиеся зависимости
 func (suite *Iteration8Suite) TearDownSuite() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -224,3 +227,43 @@ func (suite *Iteration8Suite) TestGzipCompress() {
 		}
 	})
 }
+
+// TestGzipCompressEdgeCaseURLs посылает сжатые gzip URL с граничными случаями на сокращение
+// и проверяет, что хендлер редиректа возвращает их побайтно без изменений
+func (suite *Iteration8Suite) TestGzipCompressEdgeCaseURLs() {
+	httpc := resty.New().
+		SetBaseURL(suite.serverAddress)
+
+	for i, originalURL := range generateEdgeCaseURLs(suite.T()) {
+		suite.Run(fmt.Sprintf("url_%d", i), func() {
+			// сжимаем данные с помощью gzip
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write([]byte(originalURL))
+			_ = zw.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			req := httpc.R().
+				SetContext(ctx).
+				SetBody(buf.Bytes()).
+				SetHeader("Content-Type", "text/plain").
+				SetHeader("Accept-Encoding", "gzip").
+				SetHeader("Content-Encoding", "gzip")
+			resp, err := req.Post("/")
+
+			noRespErr := suite.Assert().NoError(err, "Ошибка при попытке сделать запрос для сокращения URL")
+			validStatus := suite.Assert().Equalf(http.StatusCreated, resp.StatusCode(),
+				"Несоответствие статус кода ответа ожидаемому в хендлере '%s %s'", req.Method, req.URL)
+
+			if !noRespErr || !validStatus {
+				dump := dumpRequest(req.RawRequest, true)
+				suite.T().Logf("Оригинальный запрос:\n\n%s", dump)
+				return
+			}
+
+			assertLocationRoundTrip(suite.T(), string(resp.Body()), originalURL)
+		})
+	}
+}
@@ -41,6 +41,7 @@ func (suite *Iteration9Suite) SetupSuite() {
 
 		p := fork.NewBackgroundProcess(context.Background(), flagTargetBinaryPath,
 			fork.WithEnv(envs...),
+			instrumented.WithCoverDir(),
 		)
 		suite.serverProcess = p
 
@@ -161,6 +162,7 @@ func (suite *Iteration9Suite) TestPersistentFile() {
 // stopServer останавливает процесс сервера
 func (suite *Iteration9Suite) stopServer() {
 	exitCode, err := suite.serverProcess.Stop(syscall.SIGINT, syscall.SIGKILL)
+	fork.CheckDataRaces(suite.T(), suite.serverProcess)
 	if err != nil {
 		if errors.Is(err, os.ErrProcessDone) {
 			return
@@ -3,15 +3,35 @@ package main
 //go:generate go test -c -o=../../bin/shortenertest
 
 import (
+	"flag"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
+// instrumented содержит исполняемые файлы, собранные в режиме -race-build, и nil в остальных случаях
+var instrumented *fork.Instrumented
+
 func TestMain(m *testing.M) {
 	// Основной тест, запускает все остальные тесты
-	os.Exit(m.Run())
+	flag.Parse()
+
+	// инициализируем генераторы псевдослучайных значений, чтобы запуск можно было повторить
+	random.SetupSeed()
+
+	if !flagRaceBuild {
+		os.Exit(m.Run())
+	}
+
+	// собираем проект с race detector и сбором покрытия
+	os.Exit(fork.RunInstrumented(m, flagTargetSourcePath, flagCoverProfilePath, func(b *fork.Instrumented) {
+		flagTargetBinaryPath = b.Binary(flagBuildPackage)
+		instrumented = b
+	}, flagBuildPackage))
 }
 
 func TestIteration1(t *testing.T) {
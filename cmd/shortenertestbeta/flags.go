@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // Доступные для тест-сьютов флаги командной строки
@@ -29,4 +31,5 @@ func init() {
 	flag.StringVar(&flagBaseProfilePath, "base-profile-path", "", "path to base pprof profile")
 	flag.StringVar(&flagResultProfilePath, "result-profile-path", "", "path to result pprof profile")
 	flag.StringVar(&flagPackageName, "package-name", "", "name of package to be tested")
+	random.RegisterSeedFlag()
 }
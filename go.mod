@@ -12,6 +12,7 @@ require (
 	github.com/jingyugao/rowserrcheck v1.1.1
 	github.com/stretchr/testify v1.8.0
 	github.com/timakin/bodyclose v0.0.0-20230421092635-574207250966
+	golang.org/x/net v0.35.0
 	golang.org/x/sync v0.11.0
 	golang.org/x/tools v0.30.0
 	honnef.co/go/tools v0.6.1
@@ -41,7 +42,6 @@ require (
 	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/exp/typeparams v0.0.0-20231108232855-2478ac86f678 // indirect
 	golang.org/x/mod v0.23.0 // indirect
-	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
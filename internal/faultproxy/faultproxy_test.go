@@ -0,0 +1,229 @@
+package faultproxy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startEcho запускает TCP сервер, возвращающий каждую полученную строку
+func startEcho(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func echo(t *testing.T, c net.Conn, msg string) (string, error) {
+	t.Helper()
+	if _, err := io.WriteString(c, msg+"\n"); err != nil {
+		return "", err
+	}
+	return bufio.NewReader(c).ReadString('\n')
+}
+
+func TestTCPProxy(t *testing.T) {
+	p, err := NewTCP("", startEcho(t))
+	require.NoError(t, err)
+	defer p.Close()
+
+	t.Run("forward", func(t *testing.T) {
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		resp, err := echo(t, c, "hello")
+		require.NoError(t, err)
+		assert.Equal(t, "hello\n", resp)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		p.SetLatency(100 * time.Millisecond)
+		defer p.Clear()
+
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		start := time.Now()
+		_, err = echo(t, c, "slow")
+		require.NoError(t, err)
+		// задержка добавляется в обоих направлениях
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("bandwidth", func(t *testing.T) {
+		p.SetBandwidth(1000)
+		defer p.Clear()
+
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		start := time.Now()
+		_, err = echo(t, c, strings.Repeat("x", 299))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("reset_next", func(t *testing.T) {
+		p.ResetNext(1)
+		defer p.Clear()
+
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		_, err = echo(t, c, "reset")
+		assert.Error(t, err)
+
+		c2, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c2.Close()
+
+		resp, err := echo(t, c2, "ok")
+		require.NoError(t, err)
+		assert.Equal(t, "ok\n", resp)
+	})
+
+	t.Run("reset_all", func(t *testing.T) {
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		_, err = echo(t, c, "before")
+		require.NoError(t, err)
+
+		p.ResetAll()
+		_, err = echo(t, c, "after")
+		assert.Error(t, err)
+	})
+
+	t.Run("blackhole", func(t *testing.T) {
+		p.SetBlackhole(true)
+		defer p.Clear()
+
+		c, err := net.Dial("tcp", p.Addr())
+		require.NoError(t, err)
+		defer c.Close()
+
+		r := bufio.NewReader(c)
+		_, err = io.WriteString(c, "held\n")
+		require.NoError(t, err)
+		require.NoError(t, c.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+		_, err = r.ReadString('\n')
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+
+		// после отключения потери трафика удержанные данные доставляются
+		p.SetBlackhole(false)
+		require.NoError(t, c.SetReadDeadline(time.Now().Add(time.Second)))
+		resp, err := r.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "held\n", resp)
+	})
+
+	assert.Positive(t, p.Connections())
+}
+
+func TestHTTPProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	p, err := NewHTTP("", srv.URL)
+	require.NoError(t, err)
+	defer p.Close()
+
+	get := func(ctx context.Context) (int, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL()+"/path", nil)
+		require.NoError(t, err)
+		// отключаем повторное использование соединений, чтобы сбои не влияли на следующие запросы
+		req.Close = true
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		status, body, err := get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("fail_next", func(t *testing.T) {
+		p.FailNext(2, http.StatusServiceUnavailable)
+		defer p.Clear()
+
+		for range 2 {
+			status, _, err := get(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusServiceUnavailable, status)
+		}
+		status, _, err := get(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("reset_next", func(t *testing.T) {
+		p.ResetNext(1)
+		defer p.Clear()
+
+		_, _, err := get(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("blackhole", func(t *testing.T) {
+		p.SetBlackhole(true)
+		defer p.Clear()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, _, err := get(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		p.SetLatency(100 * time.Millisecond)
+		defer p.Clear()
+
+		start := time.Now()
+		_, _, err := get(context.Background())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	assert.Equal(t, 7, p.Requests())
+	assert.Equal(t, 4, p.Injected())
+}
@@ -0,0 +1,173 @@
+// Package faultproxy реализует локальные TCP и HTTP прокси, которые можно поставить
+// между двумя тестируемыми процессами и управлять сбоями в их взаимодействии:
+// задержками, ограничением пропускной способности, разрывами соединений,
+// потерей трафика и ответами с ошибками сервера
+package faultproxy
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// faults хранит текущие настройки сбоев прокси
+type faults struct {
+	m sync.Mutex
+
+	latency   time.Duration
+	bandwidth int
+	blackhole bool
+	resetNext int
+
+	failNext   int
+	failStatus int
+
+	// blackholeOff закрывается при отключении потери трафика
+	blackholeOff chan struct{}
+}
+
+// SetLatency добавляет задержку перед передачей каждой порции данных или каждого HTTP запроса.
+// Нулевое значение отключает задержку
+func (f *faults) SetLatency(d time.Duration) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.latency = d
+}
+
+// SetBandwidth ограничивает пропускную способность каждого соединения в байтах в секунду.
+// Нулевое значение снимает ограничение
+func (f *faults) SetBandwidth(bytesPerSecond int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.bandwidth = bytesPerSecond
+}
+
+// SetBlackhole включает или отключает потерю трафика: данные и запросы принимаются,
+// но удерживаются без передачи дальше, а соединения остаются открытыми.
+// После отключения удержанные данные и запросы передаются по назначению
+func (f *faults) SetBlackhole(on bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.blackhole == on {
+		return
+	}
+	f.blackhole = on
+	if on {
+		f.blackholeOff = make(chan struct{})
+	} else {
+		close(f.blackholeOff)
+	}
+}
+
+// ResetNext разрывает следующие n соединений (для HTTP прокси - запросов) сразу после их установки
+func (f *faults) ResetNext(n int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.resetNext = n
+}
+
+// Clear отключает все сбои
+func (f *faults) Clear() {
+	f.SetBlackhole(false)
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.latency = 0
+	f.bandwidth = 0
+	f.resetNext = 0
+	f.failNext = 0
+}
+
+// takeReset сообщает, нужно ли разорвать очередное соединение
+func (f *faults) takeReset() bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.resetNext <= 0 {
+		return false
+	}
+	f.resetNext--
+	return true
+}
+
+// takeFailure возвращает код статуса, с которым нужно ответить на очередной запрос, или 0
+func (f *faults) takeFailure() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.failNext <= 0 {
+		return 0
+	}
+	f.failNext--
+	return f.failStatus
+}
+
+// blackholed возвращает канал, который закрывается при отключении потери трафика,
+// или nil, если трафик не теряется
+func (f *faults) blackholed() <-chan struct{} {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if !f.blackhole {
+		return nil
+	}
+	return f.blackholeOff
+}
+
+// delay ожидает время заданной задержки
+func (f *faults) delay(ctx context.Context) error {
+	f.m.Lock()
+	d := f.latency
+	f.m.Unlock()
+	return sleep(ctx, d)
+}
+
+func (f *faults) currentBandwidth() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.bandwidth
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// throttledWriter передает данные с учетом ограничения пропускной способности
+type throttledWriter struct {
+	ctx context.Context
+	dst io.Writer
+	f   *faults
+}
+
+func (w throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		bw := w.f.currentBandwidth()
+		if bw <= 0 {
+			n, err := w.dst.Write(p)
+			return written + n, err
+		}
+
+		// передаем данные порциями по десятой части секундного лимита,
+		// выдерживая перед отправкой порции время, необходимое для ее передачи
+		chunk := p[:min(len(p), max(bw/10, 1))]
+		if err := sleep(w.ctx, time.Duration(len(chunk))*time.Second/time.Duration(bw)); err != nil {
+			return written, err
+		}
+		n, err := w.dst.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
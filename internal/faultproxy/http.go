@@ -0,0 +1,172 @@
+package faultproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// HTTPProxy перенаправляет HTTP запросы на целевой сервер, внося в обработку запросов настроенные сбои
+type HTTPProxy struct {
+	faults
+
+	listener net.Listener
+	server   *http.Server
+	proxy    *httputil.ReverseProxy
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	total    atomic.Int64
+	injected atomic.Int64
+}
+
+// NewHTTP запускает HTTP прокси на адресе listenAddr, перенаправляющий запросы на target.
+// target может быть URL или адресом вида host:port. Пустой listenAddr означает свободный порт на localhost
+func NewHTTP(listenAddr, target string) (*HTTPProxy, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse target URL: %w", err)
+	}
+
+	ln, err := listen(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &HTTPProxy{
+		listener: ln,
+		proxy:    httputil.NewSingleHostReverseProxy(u),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	p.proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		// запрос, прерванный клиентом или остановкой прокси, не требует ответа
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, fmt.Sprintf("faultproxy: %s", err), http.StatusBadGateway)
+	}
+	p.server = &http.Server{Handler: p}
+
+	go func() {
+		_ = p.server.Serve(ln)
+	}()
+	return p, nil
+}
+
+// Addr возвращает адрес, на котором прокси принимает запросы
+func (p *HTTPProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// URL возвращает базовый URL прокси
+func (p *HTTPProxy) URL() string {
+	return "http://" + p.Addr()
+}
+
+// FailNext отвечает на следующие n запросов кодом статуса status, не передавая их целевому серверу
+func (p *HTTPProxy) FailNext(n, status int) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.failNext = n
+	p.failStatus = status
+}
+
+// Requests возвращает количество полученных прокси запросов
+func (p *HTTPProxy) Requests() int {
+	return int(p.total.Load())
+}
+
+// Injected возвращает количество запросов, обработка которых была нарушена прокси
+func (p *HTTPProxy) Injected() int {
+	return int(p.injected.Load())
+}
+
+// Close останавливает прокси и закрывает все установленные соединения
+func (p *HTTPProxy) Close() error {
+	p.cancel()
+	err := p.server.Close()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP реализует интерфейс http.Handler
+func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.total.Add(1)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if p.takeReset() {
+		p.injected.Add(1)
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				reset(conn)
+				return
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+
+	if off := p.blackholed(); off != nil {
+		p.injected.Add(1)
+		// удерживаем запрос без ответа, пока клиент не откажется от него или потеря трафика не будет отключена
+		select {
+		case <-ctx.Done():
+			return
+		case <-off:
+		}
+	}
+
+	if status := p.takeFailure(); status != 0 {
+		p.injected.Add(1)
+		http.Error(w, fmt.Sprintf("faultproxy: injected %d response", status), status)
+		return
+	}
+
+	if err := p.delay(ctx); err != nil {
+		return
+	}
+
+	p.proxy.ServeHTTP(throttledResponseWriter{
+		ResponseWriter: w,
+		w:              throttledWriter{ctx: ctx, dst: w, f: &p.faults},
+	}, r.WithContext(ctx))
+}
+
+// throttledResponseWriter ограничивает скорость передачи тела ответа
+type throttledResponseWriter struct {
+	http.ResponseWriter
+	w throttledWriter
+}
+
+func (w throttledResponseWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+// Flush реализует интерфейс http.Flusher
+func (w throttledResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
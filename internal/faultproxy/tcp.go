@@ -0,0 +1,214 @@
+package faultproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TCPProxy перенаправляет TCP соединения на целевой адрес, внося в них настроенные сбои
+type TCPProxy struct {
+	faults
+
+	target   string
+	listener net.Listener
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	connsM sync.Mutex
+	conns  map[net.Conn]struct{}
+	total  atomic.Int64
+}
+
+// NewTCP запускает TCP прокси на адресе listenAddr, перенаправляющий соединения на адрес target.
+// Пустой listenAddr означает свободный порт на localhost
+func NewTCP(listenAddr, target string) (*TCPProxy, error) {
+	ln, err := listen(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &TCPProxy{
+		target:   target,
+		listener: ln,
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.serve()
+	return p, nil
+}
+
+// Addr возвращает адрес, на котором прокси принимает соединения
+func (p *TCPProxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// Connections возвращает количество принятых прокси соединений
+func (p *TCPProxy) Connections() int {
+	return int(p.total.Load())
+}
+
+// ResetAll разрывает все установленные через прокси соединения
+func (p *TCPProxy) ResetAll() {
+	p.connsM.Lock()
+	defer p.connsM.Unlock()
+	for c := range p.conns {
+		reset(c)
+	}
+}
+
+// Close останавливает прокси и закрывает все установленные соединения
+func (p *TCPProxy) Close() error {
+	p.cancel()
+	err := p.listener.Close()
+
+	p.connsM.Lock()
+	for c := range p.conns {
+		_ = c.Close()
+	}
+	p.connsM.Unlock()
+
+	p.wg.Wait()
+	return err
+}
+
+func (p *TCPProxy) serve() {
+	defer p.wg.Done()
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		p.total.Add(1)
+
+		if p.takeReset() {
+			reset(client)
+			continue
+		}
+
+		p.wg.Add(1)
+		go p.handle(client)
+	}
+}
+
+func (p *TCPProxy) handle(client net.Conn) {
+	defer p.wg.Done()
+
+	var d net.Dialer
+	upstream, err := d.DialContext(p.ctx, "tcp", p.target)
+	if err != nil {
+		reset(client)
+		return
+	}
+
+	if !p.track(client, upstream) {
+		_ = client.Close()
+		_ = upstream.Close()
+		return
+	}
+	defer p.untrack(client, upstream)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.pipe(upstream, client)
+	}()
+	go func() {
+		defer wg.Done()
+		p.pipe(client, upstream)
+	}()
+	wg.Wait()
+
+	_ = client.Close()
+	_ = upstream.Close()
+}
+
+// pipe передает данные из src в dst, внося настроенные сбои
+func (p *TCPProxy) pipe(dst, src net.Conn) {
+	defer closeWrite(dst)
+
+	w := throttledWriter{ctx: p.ctx, dst: dst, f: &p.faults}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			// удерживаем данные, пока потеря трафика не будет отключена
+			if off := p.blackholed(); off != nil {
+				select {
+				case <-p.ctx.Done():
+					return
+				case <-off:
+				}
+			}
+			if p.delay(p.ctx) != nil {
+				return
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				// распространяем разрыв соединения на вторую сторону
+				reset(dst)
+			}
+			return
+		}
+	}
+}
+
+func (p *TCPProxy) track(conns ...net.Conn) bool {
+	p.connsM.Lock()
+	defer p.connsM.Unlock()
+	if p.ctx.Err() != nil {
+		return false
+	}
+	for _, c := range conns {
+		p.conns[c] = struct{}{}
+	}
+	return true
+}
+
+func (p *TCPProxy) untrack(conns ...net.Conn) {
+	p.connsM.Lock()
+	defer p.connsM.Unlock()
+	for _, c := range conns {
+		delete(p.conns, c)
+	}
+}
+
+// listen начинает принимать соединения на адресе addr или на свободном порту localhost
+func listen(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = "localhost:0"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// reset закрывает соединение с отправкой RST вместо штатного завершения
+func reset(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+	_ = c.Close()
+}
+
+// closeWrite сообщает второй стороне об окончании передачи данных
+func closeWrite(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		_ = tc.CloseWrite()
+		return
+	}
+	_ = c.SetDeadline(time.Now())
+}
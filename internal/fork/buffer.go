@@ -1,33 +1,182 @@
 package fork
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"os"
 	"sync"
 )
 
-// buffer вяляется синхронной оберткой над bytes.Buffer
+// CapturePolicy описывает, какая часть вывода процесса сохраняется в памяти.
+// При превышении лимитов сохраняются только начало и конец вывода, а середина отбрасывается
+type CapturePolicy struct {
+	// HeadBytes количество первых байт вывода, которые сохраняются всегда
+	HeadBytes int
+	// TailBytes количество последних байт вывода, которые сохраняются в кольцевом буфере
+	TailBytes int
+	// SpillDir директория, в которую дополнительно записывается полный вывод процесса.
+	// Пустое значение отключает запись вывода в файл
+	SpillDir string
+	// KeepSpill оставляет файлы с полным выводом после остановки процесса.
+	// По умолчанию они удаляются в Stop и StopGraceful
+	KeepSpill bool
+}
+
+// Unbounded сообщает, что политика не ограничивает объем сохраняемого вывода
+func (c CapturePolicy) Unbounded() bool {
+	return c.HeadBytes <= 0 && c.TailBytes <= 0
+}
+
+// DefaultCapturePolicy сохраняет первый и последний мегабайт вывода процесса
+var DefaultCapturePolicy = CapturePolicy{
+	HeadBytes: 1 << 20,
+	TailBytes: 1 << 20,
+}
+
+// buffer вяляется синхронным буфером вывода процесса с ограничением объема
 type buffer struct {
-	m   sync.RWMutex
-	buf bytes.Buffer
+	m      sync.RWMutex
+	policy CapturePolicy
+
+	head    []byte
+	tail    []byte
+	tailPos int
+	tailLen int
+	dropped int64
+
+	spill    *os.File
+	spillErr error
+}
+
+// newBuffer возвращает буфер с заданной политикой сохранения вывода
+func newBuffer(policy CapturePolicy, stream Stream) *buffer {
+	b := &buffer{policy: policy}
+	if policy.TailBytes > 0 {
+		b.tail = make([]byte, policy.TailBytes)
+	}
+	if policy.SpillDir != "" {
+		b.spill, b.spillErr = os.CreateTemp(policy.SpillDir, string(stream)+"-*.log")
+	}
+	return b
 }
 
 // Write реализует интерфейс io.Writer
 func (b *buffer) Write(p []byte) (n int, err error) {
 	b.m.Lock()
 	defer b.m.Unlock()
-	return b.buf.Write(p)
+
+	if b.spill != nil {
+		if _, err := b.spill.Write(p); err != nil {
+			b.spillErr = err
+		}
+	}
+
+	if b.policy.Unbounded() {
+		b.head = append(b.head, p...)
+		return len(p), nil
+	}
+
+	rest := p
+	if free := b.policy.HeadBytes - len(b.head); free > 0 {
+		chunk := rest[:min(free, len(rest))]
+		b.head = append(b.head, chunk...)
+		rest = rest[len(chunk):]
+	}
+
+	if len(b.tail) == 0 {
+		b.dropped += int64(len(rest))
+		return len(p), nil
+	}
+
+	// в кольцевой буфер попадают только последние TailBytes байт записи
+	if len(rest) > len(b.tail) {
+		b.dropped += int64(len(rest) - len(b.tail))
+		rest = rest[len(rest)-len(b.tail):]
+	}
+	for len(rest) > 0 {
+		n := copy(b.tail[b.tailPos:], rest)
+		rest = rest[n:]
+		b.tailPos = (b.tailPos + n) % len(b.tail)
+		if b.tailLen+n > len(b.tail) {
+			b.dropped += int64(b.tailLen + n - len(b.tail))
+		}
+		b.tailLen = min(b.tailLen+n, len(b.tail))
+	}
+	return len(p), nil
+}
+
+// Bytes возвращает сохраненный вывод. Если часть вывода была отброшена,
+// на ее месте находится пометка с количеством отброшенных байт
+func (b *buffer) Bytes() []byte {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	res := make([]byte, 0, len(b.head)+b.tailLen+128)
+	res = append(res, b.head...)
+	if b.dropped > 0 {
+		res = append(res, b.truncationNote()...)
+	}
+	if b.tailLen > 0 {
+		start := (b.tailPos - b.tailLen + len(b.tail)) % len(b.tail)
+		if start+b.tailLen <= len(b.tail) {
+			res = append(res, b.tail[start:start+b.tailLen]...)
+		} else {
+			res = append(res, b.tail[start:]...)
+			res = append(res, b.tail[:b.tailPos]...)
+		}
+	}
+	return res
+}
+
+func (b *buffer) truncationNote() string {
+	note := fmt.Sprintf("\n\n... пропущено %d байт вывода", b.dropped)
+	if b.spill != nil && b.spillErr == nil {
+		note += fmt.Sprintf(", полный вывод сохранен в %s", b.spill.Name())
+	}
+	return note + " ...\n\n"
 }
 
-// Read реализует интерфейс io.Reader
-func (b *buffer) Read(p []byte) (n int, err error) {
+// Dropped возвращает количество отброшенных байт вывода
+func (b *buffer) Dropped() int64 {
 	b.m.RLock()
 	defer b.m.RUnlock()
-	return b.buf.Read(p)
+	return b.dropped
 }
 
-// Bytes возвращает все байты из буфера
-func (b *buffer) Bytes() []byte {
+// SpillPath возвращает путь до файла с полным выводом или пустую строку, если запись в файл не ведется
+func (b *buffer) SpillPath() string {
 	b.m.RLock()
 	defer b.m.RUnlock()
-	return b.buf.Bytes()
+	if b.spill == nil {
+		return ""
+	}
+	return b.spill.Name()
+}
+
+// close закрывает файл с полным выводом
+func (b *buffer) close() error {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.spill == nil {
+		return b.spillErr
+	}
+	if err := b.spill.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return b.spillErr
+}
+
+// removeSpill закрывает и удаляет файл с полным выводом, если политика не требует его сохранить
+func (b *buffer) removeSpill() error {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.spill == nil || b.policy.KeepSpill {
+		return nil
+	}
+	path := b.spill.Name()
+	if err := b.spill.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	b.spill = nil
+	return os.Remove(path)
 }
@@ -0,0 +1,52 @@
+package fork
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	t.Run("unbounded", func(t *testing.T) {
+		b := newBuffer(CapturePolicy{}, StreamStdout)
+		for i := 0; i < 1000; i++ {
+			_, _ = b.Write([]byte("0123456789"))
+		}
+		require.Len(t, b.Bytes(), 10000)
+		require.Zero(t, b.Dropped())
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		b := newBuffer(CapturePolicy{HeadBytes: 4, TailBytes: 8}, StreamStdout)
+		_, _ = b.Write([]byte("abcdef"))
+		_, _ = b.Write([]byte("ghij"))
+		require.Equal(t, "abcdefghij", string(b.Bytes()))
+		require.Zero(t, b.Dropped())
+	})
+
+	t.Run("head and tail", func(t *testing.T) {
+		b := newBuffer(CapturePolicy{HeadBytes: 4, TailBytes: 6}, StreamStdout)
+		_, _ = b.Write([]byte("abcdefgh"))
+		_, _ = b.Write([]byte("ijklmnopqrstuvwxyz"))
+		_, _ = b.Write([]byte("01"))
+
+		require.EqualValues(t, 18, b.Dropped())
+		out := string(b.Bytes())
+		require.True(t, strings.HasPrefix(out, "abcd"), out)
+		require.True(t, strings.HasSuffix(out, "wxyz01"), out)
+		require.Contains(t, out, "пропущено 18 байт")
+	})
+
+	t.Run("spill", func(t *testing.T) {
+		b := newBuffer(CapturePolicy{HeadBytes: 2, TailBytes: 2, SpillDir: t.TempDir()}, StreamStderr)
+		_, _ = b.Write([]byte("0123456789"))
+		require.NoError(t, b.close())
+
+		require.Contains(t, string(b.Bytes()), b.SpillPath())
+		full, err := os.ReadFile(b.SpillPath())
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(full))
+	})
+}
@@ -0,0 +1,169 @@
+package fork
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// buildConfig содержит параметры сборки исполняемого файла
+type buildConfig struct {
+	race     bool
+	cover    bool
+	coverPkg string
+}
+
+// BuildOpt настраивает сборку исполняемого файла
+type BuildOpt = func(c *buildConfig)
+
+// BuildWithRace включает в сборку race detector
+func BuildWithRace() BuildOpt {
+	return func(c *buildConfig) {
+		c.race = true
+	}
+}
+
+// BuildWithCover включает в сборку сбор интеграционного покрытия кода.
+// Если шаблон пакетов не указан, покрытие собирается для пакетов основного модуля
+func BuildWithCover(coverPkg string) BuildOpt {
+	return func(c *buildConfig) {
+		c.cover = true
+		c.coverPkg = coverPkg
+	}
+}
+
+// Build собирает исполняемый файл output из пакета pkg модуля, расположенного в директории srcDir
+func Build(ctx context.Context, srcDir, pkg, output string, opts ...BuildOpt) error {
+	var c buildConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	args := []string{"build", "-o=" + output}
+	if c.race {
+		args = append(args, "-race")
+	}
+	if c.cover {
+		args = append(args, "-cover")
+		if c.coverPkg != "" {
+			args = append(args, "-coverpkg="+c.coverPkg)
+		}
+	}
+	args = append(args, pkg)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = srcDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot build %s: %w\n\n%s", pkg, err, out)
+	}
+	return nil
+}
+
+// WithCoverDir указывает процессу, собранному с BuildWithCover, директорию для записи данных покрытия
+func WithCoverDir(dir string) ProcessOpt {
+	return WithEnv("GOCOVERDIR=" + dir)
+}
+
+// MergeCoverage объединяет данные интеграционного покрытия из директории coverDir
+// в профиль покрытия profile в текстовом формате, совместимом с go tool cover
+func MergeCoverage(ctx context.Context, coverDir, profile string) error {
+	out, err := exec.CommandContext(ctx, "go", "tool", "covdata", "textfmt",
+		"-i="+coverDir, "-o="+profile).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cannot merge coverage data: %w\n\n%s", err, out)
+	}
+	return nil
+}
+
+// CoverageSummary возвращает процент покрытия по пакетам на основе данных из директории coverDir
+func CoverageSummary(ctx context.Context, coverDir string) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "tool", "covdata", "percent",
+		"-i="+coverDir).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("cannot calculate coverage: %w\n\n%s", err, out)
+	}
+	return string(out), nil
+}
+
+// raceReportSeparator разделяет отчеты race detector в выводе процесса
+const raceReportSeparator = "=================="
+
+// RaceReport является отчетом race detector о найденной гонке данных
+type RaceReport struct {
+	// Text полный текст отчета
+	Text string
+}
+
+// ParseRaceReports находит в выводе процесса отчеты race detector
+func ParseRaceReports(out []byte) []RaceReport {
+	var c raceCollector
+	for line := range bytes.Lines(out) {
+		c.add(string(bytes.TrimRight(line, "\r\n")))
+	}
+	return c.reports
+}
+
+// raceCollector находит отчеты race detector в строках stderr по мере их поступления.
+// Отчеты не зависят от ограничения буфера вывода и сохраняются, даже если середина вывода была отброшена
+type raceCollector struct {
+	m       sync.Mutex
+	current *strings.Builder
+	reports []RaceReport
+}
+
+// handle обрабатывает строку вывода процесса, строки stdout игнорируются
+func (c *raceCollector) handle(line OutputLine) {
+	if line.Stream != StreamStderr {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.add(line.Text)
+}
+
+func (c *raceCollector) add(line string) {
+	switch {
+	case c.current == nil && strings.HasPrefix(line, "WARNING: DATA RACE"):
+		c.current = new(strings.Builder)
+		c.current.WriteString(line + "\n")
+	case c.current != nil && line == raceReportSeparator:
+		c.reports = append(c.reports, RaceReport{Text: strings.TrimRight(c.current.String(), "\n")})
+		c.current = nil
+	case c.current != nil:
+		c.current.WriteString(line + "\n")
+	}
+}
+
+// collected возвращает найденные отчеты
+func (c *raceCollector) collected() []RaceReport {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return slices.Clone(c.reports)
+}
+
+// take возвращает найденные отчеты и начинает сбор заново
+func (c *raceCollector) take() []RaceReport {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	reports := c.reports
+	c.reports = nil
+	c.current = nil
+	return reports
+}
+
+// RaceReports возвращает отчеты race detector, найденные в stderr процесса
+// во всех его запусках, включая завершенные перезапуском
+func (p *BackgroundProcess) RaceReports() []RaceReport {
+	var reports []RaceReport
+	for _, inc := range p.history {
+		reports = append(reports, inc.RaceReports...)
+	}
+	return append(reports, p.races.collected()...)
+}
@@ -0,0 +1,158 @@
+//go:build !windows
+
+package fork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRaceReports(t *testing.T) {
+	out := []byte(`starting server
+==================
+WARNING: DATA RACE
+Write at 0x00c0000a0010 by goroutine 7:
+  main.main.func1()
+==================
+request handled
+==================
+WARNING: DATA RACE
+Read at 0x00c0000a0018 by goroutine 8:
+==================
+Found 2 data race(s)
+`)
+
+	reports := ParseRaceReports(out)
+	require.Len(t, reports, 2)
+	require.Equal(t, "WARNING: DATA RACE\nWrite at 0x00c0000a0010 by goroutine 7:\n  main.main.func1()", reports[0].Text)
+	require.Equal(t, "WARNING: DATA RACE\nRead at 0x00c0000a0018 by goroutine 8:", reports[1].Text)
+
+	require.Empty(t, ParseRaceReports([]byte("no races here\n")))
+}
+
+func TestRaceReportsTruncatedOutput(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// отчет о гонке попадает в середину вывода, которая отбрасывается буфером
+	p := NewBackgroundProcess(ctx, "sh",
+		WithArgs("-c", `for i in $(seq 100); do echo "filler line $i" >&2; done
+printf 'WARNING: DATA RACE\nRead at 0x00\n==================\n' >&2
+for i in $(seq 100); do echo "filler line $i" >&2; done`),
+		WithCapturePolicy(CapturePolicy{HeadBytes: 64, TailBytes: 64}),
+	)
+	require.NoError(t, p.Start(ctx))
+	<-p.Done()
+
+	require.NotContains(t, string(p.Stderr(ctx)), "DATA RACE")
+	reports := p.RaceReports()
+	require.Len(t, reports, 1)
+	require.Equal(t, "WARNING: DATA RACE\nRead at 0x00", reports[0].Text)
+}
+
+func TestBuildRaceCover(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires go toolchain")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	src := racyModule(t)
+	binary := filepath.Join(t.TempDir(), "racy")
+	require.NoError(t, Build(ctx, src, ".", binary, BuildWithRace(), BuildWithCover("")))
+
+	coverDir := t.TempDir()
+	p := NewBackgroundProcess(ctx, binary, WithCoverDir(coverDir))
+	require.NoError(t, p.Start(ctx))
+	<-p.Done()
+
+	require.NotEmpty(t, p.RaceReports())
+
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, MergeCoverage(ctx, coverDir, profile))
+	data, err := os.ReadFile(profile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "racy/main.go")
+}
+
+func TestBuildInstrumented(t *testing.T) {
+	if testing.Short() {
+		t.Skip("requires go toolchain")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	b, err := BuildInstrumented(ctx, racyModule(t), ".")
+	require.NoError(t, err)
+	binary := b.Binary(".")
+	require.FileExists(t, binary)
+
+	p := NewBackgroundProcess(ctx, binary, b.WithCoverDir())
+	require.NoError(t, p.Start(ctx))
+	<-p.Done()
+
+	var rec raceRecorder
+	CheckDataRaces(&rec, p)
+	require.NotEmpty(t, rec.errors)
+
+	var out strings.Builder
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	require.NoError(t, b.Report(ctx, &out, profile))
+	require.Contains(t, out.String(), "racy")
+	require.FileExists(t, profile)
+
+	require.NoError(t, b.Close())
+	require.NoFileExists(t, binary)
+
+	var nilBuild *Instrumented
+	require.NotNil(t, nilBuild.WithCoverDir())
+}
+
+// raceRecorder сохраняет ошибки, переданные CheckDataRaces
+type raceRecorder struct {
+	errors []string
+}
+
+func (r *raceRecorder) Helper() {}
+
+func (r *raceRecorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// racyModule создает модуль, программа которого содержит гонку данных
+func racyModule(t *testing.T) string {
+	t.Helper()
+
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "go.mod"), []byte("module racy\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.go"), []byte(`package main
+
+import "sync"
+
+func main() {
+	var (
+		wg      sync.WaitGroup
+		counter int
+	)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counter++
+		}()
+	}
+	wg.Wait()
+	println(counter)
+}
+`), 0o644))
+	return src
+}
@@ -0,0 +1,58 @@
+package fork
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExitError возвращается методами ожидания, если процесс завершился раньше, чем стал готов к работе
+type ExitError struct {
+	// ExitCode статус завершения процесса, -1 если процесс был завершен сигналом
+	ExitCode int
+	// Tail последние строки вывода процесса
+	Tail []OutputLine
+}
+
+// Error реализует интерфейс error
+func (e *ExitError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "process exited prematurely with code %d", e.ExitCode)
+	if len(e.Tail) > 0 {
+		b.WriteString(", last output lines:")
+		for _, line := range e.Tail {
+			b.WriteString("\n\t")
+			b.WriteString(line.String())
+		}
+	}
+	return b.String()
+}
+
+// Unwrap позволяет проверять ошибку с помощью errors.Is(err, os.ErrProcessDone)
+func (e *ExitError) Unwrap() error {
+	return os.ErrProcessDone
+}
+
+// Done возвращает канал, который закрывается после завершения процесса
+// и вычитывания всего его вывода
+func (p *BackgroundProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// ExitState возвращает статус завершенного процесса или nil, если процесс еще работает или не был запущен
+func (p *BackgroundProcess) ExitState() *os.ProcessState {
+	select {
+	case <-p.done:
+		return p.state
+	default:
+		return nil
+	}
+}
+
+// exitError возвращает ошибку, описывающую преждевременное завершение процесса
+func (p *BackgroundProcess) exitError() error {
+	return &ExitError{
+		ExitCode: p.exitCode(),
+		Tail:     p.lines.lastLines(),
+	}
+}
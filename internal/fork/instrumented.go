@@ -0,0 +1,134 @@
+package fork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Instrumented содержит исполняемые файлы, собранные с race detector и сбором интеграционного покрытия,
+// и директорию, в которую запущенные процессы записывают данные покрытия
+type Instrumented struct {
+	dir      string
+	coverDir string
+	binaries map[string]string
+}
+
+// BuildInstrumented собирает пакеты pkgs модуля, расположенного в директории srcDir,
+// с BuildWithRace и BuildWithCover во временную директорию.
+// Временную директорию необходимо удалить вызовом Close
+func BuildInstrumented(ctx context.Context, srcDir string, pkgs ...string) (*Instrumented, error) {
+	if srcDir == "" {
+		return nil, errors.New("source directory is not set")
+	}
+
+	dir, err := os.MkdirTemp("", "autotests-build")
+	if err != nil {
+		return nil, err
+	}
+	b := &Instrumented{
+		dir:      dir,
+		coverDir: filepath.Join(dir, "cover"),
+		binaries: make(map[string]string, len(pkgs)),
+	}
+	if err := os.Mkdir(b.coverDir, 0o755); err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+
+	for i, pkg := range pkgs {
+		binary := filepath.Join(dir, fmt.Sprintf("%d-%s", i, filepath.Base(pkg)))
+		if err := Build(ctx, srcDir, pkg, binary, BuildWithRace(), BuildWithCover("")); err != nil {
+			_ = b.Close()
+			return nil, err
+		}
+		b.binaries[pkg] = binary
+	}
+	return b, nil
+}
+
+// Binary возвращает путь к исполняемому файлу, собранному из пакета pkg
+func (b *Instrumented) Binary(pkg string) string {
+	return b.binaries[pkg]
+}
+
+// WithCoverDir указывает процессу директорию для записи данных покрытия.
+// Для nil возвращает опцию, которая ничего не делает, поэтому может использоваться
+// при запуске процессов независимо от того, были ли они собраны BuildInstrumented
+func (b *Instrumented) WithCoverDir() ProcessOpt {
+	if b == nil {
+		return func(*BackgroundProcess) {}
+	}
+	return WithCoverDir(b.coverDir)
+}
+
+// Report выводит в w процент интеграционного покрытия по пакетам
+// и, если profile не пуст, сохраняет в него профиль покрытия
+func (b *Instrumented) Report(ctx context.Context, w io.Writer, profile string) error {
+	summary, err := CoverageSummary(ctx, b.coverDir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Интеграционное покрытие кода автотестами:\n%s", summary)
+
+	if profile == "" {
+		return nil
+	}
+	return MergeCoverage(ctx, b.coverDir, profile)
+}
+
+// Close удаляет собранные исполняемые файлы и данные покрытия
+func (b *Instrumented) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+// TestingM является подмножеством *testing.M, необходимым для RunInstrumented
+type TestingM interface {
+	Run() int
+}
+
+// RunInstrumented собирает пакеты pkgs модуля srcDir с race detector и сбором покрытия,
+// передает результат сборки в setup для подмены путей к исполняемым файлам и запускает тесты m.
+// После тестов выводит интеграционное покрытие и сохраняет профиль в profile, если он не пуст.
+// Возвращает код завершения для os.Exit
+func RunInstrumented(m TestingM, srcDir, profile string, setup func(b *Instrumented), pkgs ...string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	b, err := BuildInstrumented(ctx, srcDir, pkgs...)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Не удалось собрать проект из исходного кода: %s\n", err)
+		return 1
+	}
+	defer b.Close()
+
+	setup(b)
+	code := m.Run()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := b.Report(ctx, os.Stdout, profile); err != nil {
+		fmt.Fprintf(os.Stderr, "Не удалось собрать интеграционное покрытие: %s\n", err)
+	}
+	return code
+}
+
+// TestingT является подмножеством testing.TB, необходимым для CheckDataRaces
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// CheckDataRaces помечает тест проваленным, если race detector нашел гонки данных в процессе
+func CheckDataRaces(t TestingT, p *BackgroundProcess) {
+	t.Helper()
+	if p == nil {
+		return
+	}
+	for _, report := range p.RaceReports() {
+		t.Errorf("Обнаружена гонка данных в процессе %s:\n\n%s", p, report.Text)
+	}
+}
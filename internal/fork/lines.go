@@ -0,0 +1,167 @@
+package fork
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Stream обозначает поток вывода процесса
+type Stream string
+
+const (
+	StreamStdout Stream = "stdout"
+	StreamStderr Stream = "stderr"
+)
+
+// OutputLine является строкой вывода процесса с отметкой времени ее получения
+type OutputLine struct {
+	Stream Stream
+	Time   time.Time
+	Text   string
+}
+
+// String возвращает строку в человекочитаемом виде
+func (l OutputLine) String() string {
+	return fmt.Sprintf("%s [%s] %s", l.Time.Format("15:04:05.000"), l.Stream, l.Text)
+}
+
+// LineHandler вызывается для каждой новой строки вывода процесса
+type LineHandler = func(line OutputLine)
+
+// tailSize количество последних строк вывода, сохраняемых для диагностики
+const tailSize = 20
+
+// lineDispatcher рассылает строки вывода обработчикам и подписчикам
+type lineDispatcher struct {
+	m        sync.Mutex
+	handlers []LineHandler
+	subs     map[*lineSubscription]struct{}
+	tail     []OutputLine
+}
+
+type lineSubscription struct {
+	ctx context.Context
+	ch  chan OutputLine
+}
+
+// dispatch синхронно передает строку всем обработчикам и подписчикам
+func (d *lineDispatcher) dispatch(line OutputLine) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if len(d.tail) == tailSize {
+		d.tail = append(d.tail[:0], d.tail[1:]...)
+	}
+	d.tail = append(d.tail, line)
+
+	for _, h := range d.handlers {
+		h(line)
+	}
+	for sub := range d.subs {
+		select {
+		case sub.ch <- line:
+		case <-sub.ctx.Done():
+		}
+	}
+}
+
+// lastLines возвращает последние строки вывода процесса
+func (d *lineDispatcher) lastLines() []OutputLine {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return slices.Clone(d.tail)
+}
+
+// resetTail очищает сохраненные последние строки вывода
+func (d *lineDispatcher) resetTail() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.tail = nil
+}
+
+// subscribe регистрирует нового подписчика, который будет удален при отмене контекста
+func (d *lineDispatcher) subscribe(ctx context.Context) <-chan OutputLine {
+	sub := &lineSubscription{
+		ctx: ctx,
+		ch:  make(chan OutputLine, 256),
+	}
+
+	d.m.Lock()
+	if d.subs == nil {
+		d.subs = make(map[*lineSubscription]struct{})
+	}
+	d.subs[sub] = struct{}{}
+	d.m.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		d.m.Lock()
+		delete(d.subs, sub)
+		d.m.Unlock()
+		close(sub.ch)
+	}()
+
+	return sub.ch
+}
+
+// maxLineLength максимальная длина строки вывода, после которой строка принудительно разбивается
+const maxLineLength = 64 << 10
+
+// lineWriter сохраняет вывод процесса в буфер и нарезает его на строки
+type lineWriter struct {
+	stream     Stream
+	dst        io.Writer
+	dispatcher *lineDispatcher
+
+	m       sync.Mutex
+	partial []byte
+}
+
+// Write реализует интерфейс io.Writer
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	n, err = w.dst.Write(p)
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	now := time.Now()
+	w.partial = append(w.partial, p[:n]...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		w.emit(now, w.partial[:idx])
+		w.partial = w.partial[idx+1:]
+	}
+	// не позволяем процессу без переводов строки бесконечно наращивать буфер
+	for len(w.partial) >= maxLineLength {
+		w.emit(now, w.partial[:maxLineLength])
+		w.partial = w.partial[maxLineLength:]
+	}
+	return n, err
+}
+
+// flush отправляет последнюю строку, не завершенную переводом строки
+func (w *lineWriter) flush() {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if len(w.partial) > 0 {
+		w.emit(time.Now(), w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) emit(t time.Time, text []byte) {
+	w.dispatcher.dispatch(OutputLine{
+		Stream: w.stream,
+		Time:   t,
+		Text:   string(bytes.TrimRight(text, "\r")),
+	})
+}
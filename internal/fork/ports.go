@@ -0,0 +1,136 @@
+package fork
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+// templateData содержит данные, доступные в шаблонах аргументов и переменных окружения процесса
+type templateData struct {
+	// Ports выделенные процессу порты по именам
+	Ports map[string]int
+	// Addrs адреса вида localhost:port для выделенных процессу портов по именам
+	Addrs map[string]string
+	// Deps порты и адреса процессов, от которых процесс зависит в Supervisor, по именам процессов
+	Deps map[string]templateData
+}
+
+// WithAutoPort выделяет процессу свободный порт под указанным именем.
+// Порт доступен в аргументах и переменных окружения через шаблоны {{.Ports.name}} и {{.Addrs.name}},
+// а также через методы Port и Addr. Процессам, зависящим от этого процесса в Supervisor,
+// порт доступен через шаблоны {{.Deps.process.Ports.name}} и {{.Deps.process.Addrs.name}}.
+// Порт арендуется через random.LeasePort: сокет удерживается до самого запуска процесса,
+// а блокировка порта в общей директории - до отмены контекста процесса или завершения автотеста
+func WithAutoPort(name string) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		lease, err := random.LeasePort()
+		if err != nil {
+			p.optErr = fmt.Errorf("cannot allocate port %q: %w", name, err)
+			return
+		}
+		if p.ports == nil {
+			p.ports = make(map[string]int)
+			p.leases = make(map[string]*random.PortLease)
+		}
+		p.ports[name] = lease.Port()
+		p.leases[name] = lease
+	}
+}
+
+// handoffPorts освобождает сокеты арендованных портов непосредственно перед запуском процесса
+func (p *BackgroundProcess) handoffPorts() error {
+	for name, lease := range p.leases {
+		if err := lease.Handoff(); err != nil {
+			return fmt.Errorf("cannot hand off port %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// releasePorts снимает блокировки арендованных портов
+func (p *BackgroundProcess) releasePorts() {
+	for _, lease := range p.leases {
+		_ = lease.Release()
+	}
+}
+
+// Port возвращает выделенный процессу порт по имени или 0, если порт не выделялся
+func (p *BackgroundProcess) Port(name string) int {
+	return p.ports[name]
+}
+
+// Addr возвращает адрес вида localhost:port для выделенного процессу порта
+// или пустую строку, если порт не выделялся
+func (p *BackgroundProcess) Addr(name string) string {
+	port, ok := p.ports[name]
+	if !ok {
+		return ""
+	}
+	return net.JoinHostPort("localhost", strconv.Itoa(port))
+}
+
+// Ports возвращает все выделенные процессу порты по именам
+func (p *BackgroundProcess) Ports() map[string]int {
+	return maps.Clone(p.ports)
+}
+
+// withDeps делает порты процессов-зависимостей доступными в шаблонах через {{.Deps.name}}
+func withDeps(deps map[string]*BackgroundProcess) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.deps = deps
+	}
+}
+
+// templateData возвращает выделенные процессу порты и адреса для подстановки в шаблоны
+func (p *BackgroundProcess) templateData() templateData {
+	data := templateData{
+		Ports: make(map[string]int, len(p.ports)),
+		Addrs: make(map[string]string, len(p.ports)),
+	}
+	for name, port := range p.ports {
+		data.Ports[name] = port
+		data.Addrs[name] = p.Addr(name)
+	}
+	return data
+}
+
+// renderTemplates подставляет выделенные порты в аргументы и переменные окружения процесса.
+// Обрабатываются только значения, содержащие обращение к данным шаблона вида "{{.",
+// чтобы унаследованное окружение с фигурными скобками оставалось нетронутым
+func (p *BackgroundProcess) renderTemplates() error {
+	data := p.templateData()
+	data.Deps = make(map[string]templateData, len(p.deps))
+	for name, dep := range p.deps {
+		data.Deps[name] = dep.templateData()
+	}
+
+	render := func(values []string) error {
+		for i, v := range values {
+			if !strings.Contains(v, "{{.") {
+				continue
+			}
+			tmpl, err := template.New("").Option("missingkey=error").Parse(v)
+			if err != nil {
+				return fmt.Errorf("cannot parse template %q: %w", v, err)
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("cannot render template %q: %w", v, err)
+			}
+			values[i] = buf.String()
+		}
+		return nil
+	}
+
+	if err := render(p.cmd.Args[1:]); err != nil {
+		return err
+	}
+	return render(p.cmd.Env)
+}
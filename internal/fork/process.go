@@ -2,50 +2,132 @@ package fork
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
 )
 
 // BackgroundProcess является удобной оберткой над exec.Cmd
 // для работы с запущенными процессами
 type BackgroundProcess struct {
+	ctx    context.Context
 	cmd    *exec.Cmd
 	stdout *buffer
 	stderr *buffer
 
+	lines        *lineDispatcher
+	stdoutWriter *lineWriter
+	stderrWriter *lineWriter
+	races        *raceCollector
+
 	waitPortInterval    time.Duration
 	waitPortConnTimeout time.Duration
+
+	stopSignal      os.Signal
+	stopGracePeriod time.Duration
+
+	statsInterval time.Duration
+	stats         *statsCollector
+
+	capture CapturePolicy
+
+	started time.Time
+	done    chan struct{}
+	state   *os.ProcessState
+	waitErr error
+
+	history []Incarnation
+
+	ports  map[string]int
+	leases map[string]*random.PortLease
+	deps   map[string]*BackgroundProcess
+	optErr error
 }
 
 // NewBackgroundProcess returns new unstarted background process instance.
 func NewBackgroundProcess(ctx context.Context, command string, opts ...ProcessOpt) *BackgroundProcess {
 	p := &BackgroundProcess{
-		cmd:                 exec.CommandContext(ctx, command),
+		ctx:                 ctx,
+		cmd:                 newCmd(ctx, exec.CommandContext(ctx, command)),
+		lines:               new(lineDispatcher),
+		races:               new(raceCollector),
 		waitPortInterval:    100 * time.Millisecond,
 		waitPortConnTimeout: 50 * time.Millisecond,
+		stopSignal:          os.Interrupt,
+		stopGracePeriod:     10 * time.Second,
+		statsInterval:       500 * time.Millisecond,
+		capture:             DefaultCapturePolicy,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
+	p.lines.handlers = append(p.lines.handlers, p.races.handle)
+	if len(p.leases) > 0 {
+		context.AfterFunc(ctx, p.releasePorts)
+	}
 
-	p.stdout = new(buffer)
-	p.cmd.Stdout = p.stdout
-	p.stderr = new(buffer)
-	p.cmd.Stderr = p.stdout
-
+	p.reset()
 	return p
 }
 
+// newCmd настраивает команду для запуска в фоне
+func newCmd(ctx context.Context, cmd *exec.Cmd) *exec.Cmd {
+	// процесс запускается в собственной группе, чтобы сигналы
+	// доходили и до всех порожденных им процессов
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
+	// не даем процессам-потомкам, унаследовавшим stdout/stderr,
+	// бесконечно блокировать ожидание завершения
+	cmd.WaitDelay = 2 * time.Second
+	return cmd
+}
+
+// reset подготавливает буферы вывода и состояние для нового запуска процесса
+func (p *BackgroundProcess) reset() {
+	p.stdout = newBuffer(p.capture, StreamStdout)
+	p.stdoutWriter = &lineWriter{stream: StreamStdout, dst: p.stdout, dispatcher: p.lines}
+	p.cmd.Stdout = p.stdoutWriter
+	p.stderr = newBuffer(p.capture, StreamStderr)
+	p.stderrWriter = &lineWriter{stream: StreamStderr, dst: p.stderr, dispatcher: p.lines}
+	p.cmd.Stderr = p.stderrWriter
+
+	p.lines.resetTail()
+	p.stats = new(statsCollector)
+	p.done = make(chan struct{})
+	p.state = nil
+	p.waitErr = nil
+}
+
 // Start является аналогом (*exec.Cmd).Start с поддержкой контекста
 func (p *BackgroundProcess) Start(ctx context.Context) error {
+	if p.optErr != nil {
+		return p.optErr
+	}
+	if err := p.renderTemplates(); err != nil {
+		return err
+	}
+	if err := p.handoffPorts(); err != nil {
+		return err
+	}
+
 	startChan := make(chan error, 1)
 	go func() {
-		startChan <- p.cmd.Start()
+		err := p.cmd.Start()
+		if err == nil {
+			p.started = time.Now()
+			go p.wait()
+			go p.sample(p.cmd.Process.Pid, p.stats, p.done)
+		}
+		startChan <- err
 	}()
 
 	for {
@@ -58,28 +140,42 @@ func (p *BackgroundProcess) Start(ctx context.Context) error {
 	}
 }
 
+// wait дожидается завершения процесса и сохраняет его статус
+func (p *BackgroundProcess) wait() {
+	err := p.cmd.Wait()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// ненулевой статус не является ошибкой ожидания
+		err = nil
+	}
+
+	p.stdoutWriter.flush()
+	p.stderrWriter.flush()
+	_ = p.stdout.close()
+	_ = p.stderr.close()
+
+	p.state = p.cmd.ProcessState
+	p.waitErr = err
+	p.collectUsage()
+	close(p.done)
+}
+
 // WaitPort позволяет дождаться занятия порта процессом
 func (p *BackgroundProcess) WaitPort(ctx context.Context, network, port string) error {
-	ticker := time.NewTicker(p.waitPortInterval)
-	defer ticker.Stop()
-
 	port = strings.TrimLeft(port, ":")
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			conn, _ := net.DialTimeout(network, ":"+port, p.waitPortConnTimeout)
-			if conn != nil {
-				_ = conn.Close()
-				return nil
-			}
+	return p.poll(ctx, func() bool {
+		conn, _ := net.DialTimeout(network, ":"+port, p.waitPortConnTimeout)
+		if conn != nil {
+			_ = conn.Close()
+			return true
 		}
-	}
+		return false
+	})
 }
 
-// ListenPort позволяет проверить наличие свободного порта
+// ListenPort позволяет дождаться, пока процесс начнет отправлять данные на указанный порт
 func (p *BackgroundProcess) ListenPort(ctx context.Context, network, port string) error {
 	ticker := time.NewTicker(p.waitPortInterval)
 	defer ticker.Stop()
@@ -90,6 +186,8 @@ func (p *BackgroundProcess) ListenPort(ctx context.Context, network, port string
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-p.done:
+			return p.exitError()
 		case <-ticker.C:
 			lc := &net.ListenConfig{}
 			ln, _ := lc.Listen(ctx, network, ":"+port)
@@ -106,6 +204,8 @@ func (p *BackgroundProcess) ListenPort(ctx context.Context, network, port string
 				select {
 				case <-done:
 					return nil
+				case <-p.done:
+					return p.exitError()
 				case <-ctx.Done():
 					return ctx.Err()
 				}
@@ -124,24 +224,76 @@ func (p *BackgroundProcess) Stderr(ctx context.Context) []byte {
 	return p.stderr.Bytes()
 }
 
-// Stop пытается остановить процесс последовательной передачей процессу данных сигналов
+// Dropped возвращает количество байт вывода в поток stream, отброшенных согласно политике сохранения вывода
+func (p *BackgroundProcess) Dropped(stream Stream) int64 {
+	return p.output(stream).Dropped()
+}
+
+// SpillPath возвращает путь до файла с полным выводом в поток stream
+// или пустую строку, если политика сохранения вывода не предусматривает запись в файл
+// или файл уже удален при остановке процесса
+func (p *BackgroundProcess) SpillPath(stream Stream) string {
+	return p.output(stream).SpillPath()
+}
+
+func (p *BackgroundProcess) output(stream Stream) *buffer {
+	if stream == StreamStderr {
+		return p.stderr
+	}
+	return p.stdout
+}
+
+// Subscribe возвращает канал, в который построчно передается вывод процесса из stdout и stderr.
+// Подписка действует до отмены контекста, после чего канал закрывается.
+// Канал необходимо вычитывать, иначе запись вывода процесса будет заблокирована
+func (p *BackgroundProcess) Subscribe(ctx context.Context) <-chan OutputLine {
+	return p.lines.subscribe(ctx)
+}
+
+// Stop пытается остановить процесс последовательной передачей группе процесса данных сигналов.
+// Если процесс не завершился в течение периода ожидания, группа процесса принудительно завершается.
+// Сигнатура Stop сохранена ради совместимости с автотестами, поэтому данные о потреблении ресурсов
+// после остановки доступны через Stats, а StopGraceful возвращает их в StopResult.Stats
 func (p *BackgroundProcess) Stop(signals ...os.Signal) (exitCode int, err error) {
+	if err := p.checkStarted(); err != nil {
+		return -1, err
+	}
+
+	select {
+	case <-p.done:
+		p.removeSpill()
+		return p.exitCode(), fmt.Errorf("error sending signal to process: %w", os.ErrProcessDone)
+	default:
+	}
+
 	for _, sig := range signals {
-		err = p.cmd.Process.Signal(sig)
+		err = signalProcessGroup(p.cmd.Process, sig)
 		if err == nil {
 			break
 		}
 	}
 
-	if err != nil {
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
 		return -1, fmt.Errorf("error sending signal to process: %w", err)
 	}
 
-	state, err := p.cmd.Process.Wait()
-	if state == nil {
-		return -1, err
+	timer := time.NewTimer(p.stopGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-p.done:
+	case <-timer.C:
+		_ = killProcessGroup(p.cmd.Process)
+		<-p.done
 	}
-	return state.ExitCode(), err
+	p.removeSpill()
+	return p.exitCode(), p.waitErr
+}
+
+// removeSpill удаляет файлы с полным выводом завершившегося процесса
+func (p *BackgroundProcess) removeSpill() {
+	_ = p.stdout.removeSpill()
+	_ = p.stderr.removeSpill()
 }
 
 // String возвращает человекочитаемую команду, которая породила процесс
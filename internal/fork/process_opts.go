@@ -1,19 +1,22 @@
 package fork
 
 import (
+	"os"
 	"time"
 )
 
 type ProcessOpt = func(p *BackgroundProcess)
 
-// WithEnv добавляет переменные окружения вида KEY=VALUE процессу
+// WithEnv добавляет переменные окружения вида KEY=VALUE процессу.
+// Значения могут содержать шаблоны выделенных портов, см. WithAutoPort
 func WithEnv(env ...string) ProcessOpt {
 	return func(p *BackgroundProcess) {
 		p.cmd.Env = append(p.cmd.Env, env...)
 	}
 }
 
-// WithArgs добавляет процессу аргументы командной строки
+// WithArgs добавляет процессу аргументы командной строки.
+// Аргументы могут содержать шаблоны выделенных портов, см. WithAutoPort
 func WithArgs(args ...string) ProcessOpt {
 	return func(p *BackgroundProcess) {
 		p.cmd.Args = append(p.cmd.Args, args...)
@@ -33,3 +36,42 @@ func WaitPortInterval(d time.Duration) ProcessOpt {
 		p.waitPortInterval = d
 	}
 }
+
+// WithLineHandler добавляет обработчик, вызываемый для каждой строки вывода процесса.
+// Обработчики вызываются последовательно в порядке получения строк
+func WithLineHandler(h LineHandler) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.lines.handlers = append(p.lines.handlers, h)
+	}
+}
+
+// WithStopSignal устанавливает сигнал, с которого начинается корректная остановка процесса
+func WithStopSignal(sig os.Signal) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.stopSignal = sig
+	}
+}
+
+// WithStopGracePeriod устанавливает время ожидания завершения процесса в Stop,
+// после которого группа процесса принудительно завершается
+func WithStopGracePeriod(d time.Duration) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.stopGracePeriod = d
+	}
+}
+
+// WithStatsInterval устанавливает интервал опроса потребления ресурсов процессом,
+// нулевое значение отключает опрос
+func WithStatsInterval(d time.Duration) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.statsInterval = d
+	}
+}
+
+// WithCapturePolicy устанавливает политику сохранения вывода процесса в памяти.
+// Нулевое значение политики снимает ограничения на объем сохраняемого вывода
+func WithCapturePolicy(policy CapturePolicy) ProcessOpt {
+	return func(p *BackgroundProcess) {
+		p.capture = policy
+	}
+}
@@ -0,0 +1,291 @@
+//go:build !windows
+
+package fork
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundProcessSeparateStreams(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		m     sync.Mutex
+		lines []OutputLine
+	)
+	p := NewBackgroundProcess(ctx, "sh",
+		WithArgs("-c", "echo out; echo err 1>&2; sleep 10"),
+		WithLineHandler(func(line OutputLine) {
+			m.Lock()
+			defer m.Unlock()
+			lines = append(lines, line)
+		}),
+	)
+	sub := p.Subscribe(ctx)
+	require.NoError(t, p.Start(ctx))
+
+	got := make(map[Stream]string)
+	for len(got) < 2 {
+		line := <-sub
+		got[line.Stream] = line.Text
+	}
+	require.Equal(t, "out", got[StreamStdout])
+	require.Equal(t, "err", got[StreamStderr])
+
+	_, _ = p.Stop(syscall.SIGKILL)
+
+	require.Equal(t, "out\n", string(p.Stdout(ctx)))
+	require.Equal(t, "err\n", string(p.Stderr(ctx)))
+	m.Lock()
+	defer m.Unlock()
+	require.Len(t, lines, 2)
+}
+
+func TestBackgroundProcessStopGraceful(t *testing.T) {
+	t.Run("signal", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := NewBackgroundProcess(ctx, "sleep", WithArgs("10"))
+		require.NoError(t, p.Start(ctx))
+
+		res, err := p.StopGraceful(ctx, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, StopStageSignal, res.Stage)
+	})
+
+	t.Run("kill", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// процесс и его потомок игнорируют SIGINT
+		p := NewBackgroundProcess(ctx, "sh",
+			WithArgs("-c", "trap '' INT; sleep 10 & wait"),
+		)
+		require.NoError(t, p.Start(ctx))
+		time.Sleep(200 * time.Millisecond)
+
+		res, err := p.StopGraceful(ctx, 200*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, StopStageKill, res.Stage)
+		require.Less(t, res.Duration, 3*time.Second)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := NewBackgroundProcess(ctx, "sh",
+			WithArgs("-c", "trap '' INT; sleep 10 & wait"),
+		)
+		require.NoError(t, p.Start(ctx))
+		time.Sleep(200 * time.Millisecond)
+
+		// контекст отменяется во время ожидания корректного завершения
+		stopCtx, stopCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer stopCancel()
+
+		res, err := p.StopGraceful(stopCtx, 5*time.Second)
+		require.NoError(t, err)
+		require.Equal(t, StopStageKill, res.Stage)
+		require.Equal(t, -1, res.ExitCode)
+
+		select {
+		case <-p.done:
+		default:
+			t.Fatal("process is not reaped after StopGraceful")
+		}
+	})
+
+	t.Run("exited", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		p := NewBackgroundProcess(ctx, "sh", WithArgs("-c", "exit 3"))
+		require.NoError(t, p.Start(ctx))
+		time.Sleep(200 * time.Millisecond)
+
+		res, err := p.StopGraceful(ctx, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, StopStageExited, res.Stage)
+		require.Equal(t, 3, res.ExitCode)
+	})
+}
+
+func TestBackgroundProcessWaitReady(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := NewBackgroundProcess(ctx, "sh",
+		WithArgs("-c", "sleep 0.2; echo 'server started' 1>&2; sleep 10"),
+	)
+	require.NoError(t, p.Start(ctx))
+	defer p.StopGraceful(ctx, time.Second)
+
+	err := p.WaitReady(ctx,
+		HTTPProbe(srv.URL, http.StatusNoContent),
+		OutputProbe(regexp.MustCompile(`server\s+started`)),
+	)
+	require.NoError(t, err)
+
+	// уже полученный вывод также учитывается
+	require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile(`started$`)))
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer shortCancel()
+	err = p.WaitReady(shortCtx, HTTPProbe(srv.URL, http.StatusOK))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBackgroundProcessExitsBeforeReady(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := NewBackgroundProcess(ctx, "sh",
+		WithArgs("-c", "echo 'panic: boom' 1>&2; exit 2"),
+	)
+	require.NoError(t, p.Start(ctx))
+
+	start := time.Now()
+	err := p.WaitPort(ctx, "tcp", "1")
+	require.Less(t, time.Since(start), time.Second)
+
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 2, exitErr.ExitCode)
+	require.Len(t, exitErr.Tail, 1)
+	require.Equal(t, "panic: boom", exitErr.Tail[0].Text)
+	require.ErrorIs(t, err, os.ErrProcessDone)
+
+	<-p.Done()
+	require.NotNil(t, p.ExitState())
+	require.Equal(t, 2, p.ExitState().ExitCode())
+
+	err = p.WaitOutput(ctx, regexp.MustCompile("never"))
+	require.ErrorAs(t, err, &exitErr)
+	require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile("boom")))
+}
+
+func TestBackgroundProcessRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// процесс сообщает о гонке в stderr, чтобы проверить сохранение отчетов между запусками
+	p := NewBackgroundProcess(ctx, "sh",
+		WithArgs("-c", `printf 'WARNING: DATA RACE\nRead at 0x00\n==================\n' >&2; echo "started $MARK"; exec sleep 10`),
+		WithEnv("MARK=ok"),
+	)
+	require.NoError(t, p.Start(ctx))
+	require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile("started ok")))
+
+	require.NoError(t, p.Restart(ctx))
+	require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile("started ok")))
+
+	history := p.Incarnations()
+	require.Len(t, history, 1)
+	require.Equal(t, "started ok\n", string(history[0].Stdout))
+	require.Equal(t, "started ok\n", string(p.Stdout(ctx)))
+	require.Len(t, history[0].RaceReports, 1)
+	// stdout и stderr читаются независимо, поэтому отчет текущего запуска может появиться позже
+	require.Eventually(t, func() bool { return len(p.RaceReports()) == 2 }, time.Second, 10*time.Millisecond)
+
+	res, err := p.StopGraceful(ctx, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, StopStageSignal, res.Stage)
+}
+
+func TestBackgroundProcessSpillRemoved(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run := func(t *testing.T, policy CapturePolicy, stop func(p *BackgroundProcess)) []os.DirEntry {
+		policy.SpillDir = t.TempDir()
+		p := NewBackgroundProcess(ctx, "sh",
+			WithArgs("-c", "echo 0123456789; exec sleep 10"),
+			WithCapturePolicy(policy),
+		)
+		require.NoError(t, p.Start(ctx))
+		require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile("0123456789")))
+		stop(p)
+
+		entries, err := os.ReadDir(policy.SpillDir)
+		require.NoError(t, err)
+		return entries
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		entries := run(t, CapturePolicy{HeadBytes: 2, TailBytes: 2}, func(p *BackgroundProcess) {
+			_, _ = p.Stop(syscall.SIGKILL)
+			require.Empty(t, p.SpillPath(StreamStdout))
+			require.NotContains(t, string(p.Stdout(ctx)), "полный вывод сохранен")
+		})
+		require.Empty(t, entries)
+	})
+
+	t.Run("stop graceful", func(t *testing.T) {
+		entries := run(t, CapturePolicy{HeadBytes: 2, TailBytes: 2}, func(p *BackgroundProcess) {
+			_, err := p.StopGraceful(ctx, time.Second)
+			require.NoError(t, err)
+		})
+		require.Empty(t, entries)
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		entries := run(t, CapturePolicy{HeadBytes: 2, TailBytes: 2, KeepSpill: true}, func(p *BackgroundProcess) {
+			_, err := p.StopGraceful(ctx, time.Second)
+			require.NoError(t, err)
+			full, err := os.ReadFile(p.SpillPath(StreamStdout))
+			require.NoError(t, err)
+			require.Equal(t, "0123456789\n", string(full))
+		})
+		require.Len(t, entries, 2)
+	})
+}
+
+func TestBackgroundProcessAutoPort(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := NewBackgroundProcess(ctx, "sh",
+		WithAutoPort("server"),
+		WithArgs("-c", `echo "$ADDRESS $1"; exec sleep 10`, "sh", "-port={{.Ports.server}}"),
+		WithEnv("ADDRESS={{.Addrs.server}}", "PS1={{ not a template }}"),
+	)
+	port := p.Port("server")
+	require.Positive(t, port)
+	require.Equal(t, fmt.Sprintf("localhost:%d", port), p.Addr("server"))
+
+	_, err := net.Listen("tcp", p.Addr("server"))
+	require.Error(t, err, "port must be held until process start")
+
+	require.NoError(t, p.Start(ctx))
+	defer p.StopGraceful(ctx, time.Second)
+
+	ln, err := net.Listen("tcp", p.Addr("server"))
+	require.NoError(t, err, "port must be handed off to process on start")
+	require.NoError(t, ln.Close())
+
+	expected := fmt.Sprintf("localhost:%d -port=%d", port, port)
+	require.NoError(t, p.WaitOutput(ctx, regexp.MustCompile(regexp.QuoteMeta(expected))))
+
+	broken := NewBackgroundProcess(ctx, "sh", WithArgs("{{.Ports.unknown}}"))
+	require.ErrorContains(t, broken.Start(ctx), "cannot render template")
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package fork
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup ничего не делает, так как Windows не поддерживает группы процессов в понимании POSIX
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup передает сигнал процессу
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	if p == nil {
+		return errors.New("process is not started")
+	}
+	return p.Signal(sig)
+}
+
+// killProcessGroup принудительно завершает процесс
+func killProcessGroup(p *os.Process) error {
+	if p == nil {
+		return errors.New("process is not started")
+	}
+	return p.Kill()
+}
@@ -0,0 +1,159 @@
+package fork
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ReadinessProbe проверяет готовность процесса к работе,
+// блокируясь до готовности процесса или отмены контекста
+type ReadinessProbe = func(ctx context.Context, p *BackgroundProcess) error
+
+// PortProbe возвращает проверку готовности, ожидающую занятия порта процессом
+func PortProbe(network, port string) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		return p.WaitPort(ctx, network, port)
+	}
+}
+
+// AutoPortProbe возвращает проверку готовности, ожидающую занятия процессом порта,
+// выделенного ему через WithAutoPort под указанным именем
+func AutoPortProbe(network, name string) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		return p.WaitPort(ctx, network, strconv.Itoa(p.Port(name)))
+	}
+}
+
+// ListenProbe возвращает проверку готовности, ожидающую отправки процессом данных на порт
+func ListenProbe(network, port string) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		return p.ListenPort(ctx, network, port)
+	}
+}
+
+// HTTPProbe возвращает проверку готовности, ожидающую ответа процесса по HTTP
+func HTTPProbe(url string, acceptStatus ...int) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		return p.WaitHTTP(ctx, url, acceptStatus...)
+	}
+}
+
+// OutputProbe возвращает проверку готовности, ожидающую появления строки в выводе процесса
+func OutputProbe(re *regexp.Regexp) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		return p.WaitOutput(ctx, re)
+	}
+}
+
+// AllOf объединяет проверки готовности в одну, которая выполняет их параллельно
+// и считается пройденной только после успешного прохождения всех проверок
+func AllOf(probes ...ReadinessProbe) ReadinessProbe {
+	return func(ctx context.Context, p *BackgroundProcess) error {
+		g, ctx := errgroup.WithContext(ctx)
+		for _, probe := range probes {
+			g.Go(func() error {
+				return probe(ctx, p)
+			})
+		}
+		return g.Wait()
+	}
+}
+
+// WaitReady позволяет дождаться прохождения всех переданных проверок готовности
+func (p *BackgroundProcess) WaitReady(ctx context.Context, probes ...ReadinessProbe) error {
+	return AllOf(probes...)(ctx, p)
+}
+
+// WaitHTTP позволяет дождаться ответа процесса на HTTP запрос по адресу url.
+// Если статусы ответа не переданы, то подходящим считается любой полученный ответ
+func (p *BackgroundProcess) WaitHTTP(ctx context.Context, url string, acceptStatus ...int) error {
+	httpc := &http.Client{
+		Timeout: p.waitPortConnTimeout * 10,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return p.poll(ctx, func() bool {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := httpc.Do(req)
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return len(acceptStatus) == 0 || slices.Contains(acceptStatus, resp.StatusCode)
+	})
+}
+
+// WaitOutput позволяет дождаться появления в stdout или stderr процесса строки, подходящей под регулярное выражение
+func (p *BackgroundProcess) WaitOutput(ctx context.Context, re *regexp.Regexp) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// подписываемся до проверки уже полученного вывода, чтобы не пропустить строки
+	lines := p.Subscribe(ctx)
+
+	for _, out := range [][]byte{p.stdout.Bytes(), p.stderr.Bytes()} {
+		for line := range bytes.Lines(out) {
+			if re.Match(bytes.TrimRight(line, "\r\n")) {
+				return nil
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.done:
+			// весь вывод процесса уже разослан подписчикам, дочитываем оставшиеся строки
+			for {
+				select {
+				case line := <-lines:
+					if re.MatchString(line.Text) {
+						return nil
+					}
+				default:
+					return p.exitError()
+				}
+			}
+		case line, ok := <-lines:
+			if !ok {
+				return ctx.Err()
+			}
+			if re.MatchString(line.Text) {
+				return nil
+			}
+		}
+	}
+}
+
+// poll периодически вызывает check до получения положительного результата, отмены контекста
+// или преждевременного завершения процесса
+func (p *BackgroundProcess) poll(ctx context.Context, check func() bool) error {
+	ticker := time.NewTicker(p.waitPortInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.done:
+			return p.exitError()
+		case <-ticker.C:
+			if check() {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package fork
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// Incarnation описывает один завершенный запуск процесса
+type Incarnation struct {
+	// Started время запуска процесса
+	Started time.Time
+	// ExitCode статус завершения процесса, -1 если процесс был завершен сигналом
+	ExitCode int
+	// Stdout вывод процесса в stdout
+	Stdout []byte
+	// Stderr вывод процесса в stderr
+	Stderr []byte
+	// Stats потребление ресурсов процессом
+	Stats ProcessStats
+	// RaceReports отчеты race detector, найденные в stderr процесса
+	RaceReports []RaceReport
+}
+
+// Restart корректно останавливает процесс и запускает его заново с теми же аргументами и переменными окружения.
+// Вывод и статус предыдущего запуска сохраняются в истории запусков
+func (p *BackgroundProcess) Restart(ctx context.Context) error {
+	if err := p.checkStarted(); err != nil {
+		return err
+	}
+
+	if _, err := p.StopGraceful(ctx, p.stopGracePeriod); err != nil {
+		return err
+	}
+
+	p.history = append(p.history, Incarnation{
+		Started:     p.started,
+		ExitCode:    p.exitCode(),
+		Stdout:      slices.Clone(p.stdout.Bytes()),
+		Stderr:      slices.Clone(p.stderr.Bytes()),
+		Stats:       p.Stats(),
+		RaceReports: p.races.take(),
+	})
+
+	prev := p.cmd
+	cmd := exec.CommandContext(p.ctx, prev.Path)
+	cmd.Args = slices.Clone(prev.Args)
+	cmd.Env = slices.Clone(prev.Env)
+	cmd.Dir = prev.Dir
+	p.cmd = newCmd(p.ctx, cmd)
+
+	p.reset()
+	return p.Start(ctx)
+}
+
+// Incarnations возвращает историю завершенных перезапуском запусков процесса
+// в порядке их следования. Текущий запуск в историю не входит
+func (p *BackgroundProcess) Incarnations() []Incarnation {
+	return slices.Clone(p.history)
+}
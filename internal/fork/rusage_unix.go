@@ -0,0 +1,22 @@
+//go:build !windows
+
+package fork
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// maxRSS возвращает максимальный объем резидентной памяти завершенного процесса в байтах
+func maxRSS(state *os.ProcessState) int64 {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return 0
+	}
+	// на darwin значение возвращается в байтах, на остальных платформах в килобайтах
+	if runtime.GOOS == "darwin" {
+		return int64(ru.Maxrss)
+	}
+	return int64(ru.Maxrss) * 1024
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package fork
+
+import (
+	"os"
+)
+
+// maxRSS не поддерживается на Windows
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,111 @@
+package fork
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errStatsUnsupported возвращается, если опрос потребления ресурсов не поддерживается платформой
+var errStatsUnsupported = errors.New("process sampling is not supported on this platform")
+
+// ProcessStats описывает потребление ресурсов процессом
+type ProcessStats struct {
+	// Samples количество успешных опросов процесса во время работы
+	Samples int
+	// PeakRSS максимальный объем резидентной памяти процесса в байтах по данным опросов
+	PeakRSS int64
+	// PeakFDs максимальное количество открытых файловых дескрипторов по данным опросов
+	PeakFDs int
+	// PeakThreads максимальное количество потоков по данным опросов
+	PeakThreads int
+
+	// MaxRSS максимальный объем резидентной памяти в байтах по данным rusage после завершения процесса
+	MaxRSS int64
+	// UserTime процессорное время, затраченное в пространстве пользователя
+	UserTime time.Duration
+	// SystemTime процессорное время, затраченное в пространстве ядра
+	SystemTime time.Duration
+}
+
+// String возвращает человекочитаемое описание потребления ресурсов
+func (s ProcessStats) String() string {
+	return fmt.Sprintf("max RSS: %.1f MiB, peak RSS: %.1f MiB, peak FDs: %d, peak threads: %d, user CPU: %s, system CPU: %s (samples: %d)",
+		float64(s.MaxRSS)/(1<<20), float64(s.PeakRSS)/(1<<20), s.PeakFDs, s.PeakThreads, s.UserTime, s.SystemTime, s.Samples)
+}
+
+// processSample является результатом однократного опроса процесса
+type processSample struct {
+	rss     int64
+	fds     int
+	threads int
+}
+
+// statsCollector накапливает данные о потреблении ресурсов процессом
+type statsCollector struct {
+	m     sync.Mutex
+	stats ProcessStats
+}
+
+func (c *statsCollector) add(s processSample) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.stats.Samples++
+	c.stats.PeakRSS = max(c.stats.PeakRSS, s.rss)
+	c.stats.PeakFDs = max(c.stats.PeakFDs, s.fds)
+	c.stats.PeakThreads = max(c.stats.PeakThreads, s.threads)
+}
+
+func (c *statsCollector) get() ProcessStats {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.stats
+}
+
+// Stats возвращает данные о потреблении ресурсов процессом.
+// Данные rusage доступны только после завершения процесса
+func (p *BackgroundProcess) Stats() ProcessStats {
+	return p.stats.get()
+}
+
+// sample периодически опрашивает запущенный процесс до его завершения
+func (p *BackgroundProcess) sample(pid int, stats *statsCollector, done <-chan struct{}) {
+	if p.statsInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		s, err := sampleProcess(pid)
+		if errors.Is(err, errStatsUnsupported) {
+			return
+		}
+		if err == nil {
+			stats.add(s)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectUsage сохраняет данные rusage завершенного процесса
+func (p *BackgroundProcess) collectUsage() {
+	if p.state == nil {
+		return
+	}
+
+	p.stats.m.Lock()
+	defer p.stats.m.Unlock()
+
+	p.stats.stats.MaxRSS = maxRSS(p.state)
+	p.stats.stats.UserTime = p.state.UserTime()
+	p.stats.stats.SystemTime = p.state.SystemTime()
+}
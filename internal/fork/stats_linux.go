@@ -0,0 +1,47 @@
+package fork
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// sampleProcess считывает текущее потребление ресурсов процессом из /proc/<pid>
+func sampleProcess(pid int) (processSample, error) {
+	var s processSample
+
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return s, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		key, value, ok := bytes.Cut(scanner.Bytes(), []byte(":"))
+		if !ok {
+			continue
+		}
+		fields := bytes.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch string(key) {
+		case "VmRSS":
+			kb, _ := strconv.ParseInt(string(fields[0]), 10, 64)
+			s.rss = kb * 1024
+		case "Threads":
+			s.threads, _ = strconv.Atoi(string(fields[0]))
+		}
+	}
+
+	fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return s, err
+	}
+	s.fds = len(fds)
+
+	return s, nil
+}
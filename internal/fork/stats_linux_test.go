@@ -0,0 +1,33 @@
+package fork
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundProcessStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p := NewBackgroundProcess(ctx, "sleep",
+		WithArgs("10"),
+		WithStatsInterval(50*time.Millisecond),
+	)
+	require.NoError(t, p.Start(ctx))
+	time.Sleep(300 * time.Millisecond)
+
+	running := p.Stats()
+	require.Positive(t, running.Samples)
+	require.Positive(t, running.PeakRSS)
+	require.Positive(t, running.PeakFDs)
+	require.Positive(t, running.PeakThreads)
+	require.Zero(t, running.MaxRSS)
+
+	res, err := p.StopGraceful(ctx, time.Second)
+	require.NoError(t, err)
+	require.Positive(t, res.Stats.MaxRSS)
+	require.GreaterOrEqual(t, res.Stats.Samples, running.Samples)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package fork
+
+// sampleProcess не поддерживается на платформах без /proc
+func sampleProcess(pid int) (processSample, error) {
+	return processSample{}, errStatsUnsupported
+}
@@ -0,0 +1,130 @@
+package fork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StopStage обозначает этап остановки, на котором завершился процесс
+type StopStage int
+
+const (
+	// StopStageExited означает, что процесс завершился самостоятельно до начала остановки
+	StopStageExited StopStage = iota
+	// StopStageSignal означает, что процесс корректно завершился после сигнала остановки
+	StopStageSignal
+	// StopStageKill означает, что процесс был принудительно завершен сигналом SIGKILL
+	StopStageKill
+)
+
+// String возвращает человекочитаемое название этапа остановки
+func (s StopStage) String() string {
+	switch s {
+	case StopStageExited:
+		return "завершился самостоятельно"
+	case StopStageSignal:
+		return "завершился по сигналу остановки"
+	case StopStageKill:
+		return "завершен принудительно"
+	default:
+		return fmt.Sprintf("StopStage(%d)", int(s))
+	}
+}
+
+// StopResult описывает результат остановки процесса
+type StopResult struct {
+	// Stage этап, на котором завершился процесс
+	Stage StopStage
+	// ExitCode статус завершения процесса, -1 если процесс был завершен сигналом
+	ExitCode int
+	// Duration время от начала остановки до завершения процесса
+	Duration time.Duration
+	// Stats потребление ресурсов процессом за время работы
+	Stats ProcessStats
+}
+
+// killWaitTimeout ограничивает ожидание завершения процесса после SIGKILL.
+// Превышает cmd.WaitDelay, чтобы успели закрыться потоки вывода
+const killWaitTimeout = 5 * time.Second
+
+// StopGraceful отправляет сигнал остановки всей группе процесса и ожидает его завершения в течение grace.
+// Если процесс не успел завершиться, группа процесса принудительно завершается сигналом SIGKILL.
+// Отмена контекста приводит к немедленному принудительному завершению,
+// после которого StopGraceful все равно дожидается завершения процесса
+func (p *BackgroundProcess) StopGraceful(ctx context.Context, grace time.Duration) (StopResult, error) {
+	var res StopResult
+	if err := p.checkStarted(); err != nil {
+		return res, err
+	}
+
+	start := time.Now()
+	finish := func(stage StopStage) (StopResult, error) {
+		res.Stage = stage
+		res.ExitCode = p.exitCode()
+		res.Duration = time.Since(start)
+		res.Stats = p.Stats()
+		p.removeSpill()
+		return res, p.waitErr
+	}
+
+	select {
+	case <-p.done:
+		return finish(StopStageExited)
+	default:
+	}
+
+	if err := signalProcessGroup(p.cmd.Process, p.stopSignal); err == nil {
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		select {
+		case <-p.done:
+			return finish(StopStageSignal)
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	if err := killProcessGroup(p.cmd.Process); err != nil {
+		select {
+		case <-p.done:
+			return finish(StopStageSignal)
+		default:
+		}
+		return res, fmt.Errorf("cannot kill process group: %w", err)
+	}
+
+	// контекст к этому моменту может быть уже отменен, поэтому ожидаем по собственному таймеру
+	timer := time.NewTimer(killWaitTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.done:
+		return finish(StopStageKill)
+	case <-timer.C:
+		return res, fmt.Errorf("process did not exit in %s after SIGKILL", killWaitTimeout)
+	}
+}
+
+// checkStarted проверяет, что процесс был запущен
+func (p *BackgroundProcess) checkStarted() error {
+	if p.cmd.Process == nil {
+		return errors.New("process is not started")
+	}
+	return nil
+}
+
+// exitCode возвращает статус завершения процесса, -1 если процесс еще не завершен или был завершен сигналом
+func (p *BackgroundProcess) exitCode() int {
+	select {
+	case <-p.done:
+	default:
+		return -1
+	}
+	if p.state == nil {
+		return -1
+	}
+	return p.state.ExitCode()
+}
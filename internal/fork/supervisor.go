@@ -0,0 +1,196 @@
+package fork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessSpec описывает процесс, которым управляет Supervisor
+type ProcessSpec struct {
+	// Name уникальное имя процесса
+	Name string
+	// Command путь к исполняемому файлу
+	Command string
+	// Opts опции запуска процесса
+	Opts []ProcessOpt
+	// DependsOn имена процессов, которые должны быть запущены и готовы к работе до запуска этого процесса.
+	// Выделенные им через WithAutoPort порты доступны в шаблонах аргументов и переменных окружения
+	// как {{.Deps.name.Ports.port}} и {{.Deps.name.Addrs.port}}
+	DependsOn []string
+	// Ready проверки готовности процесса к работе
+	Ready []ReadinessProbe
+}
+
+// Supervisor запускает группу связанных процессов в порядке их зависимостей
+// и останавливает их в обратном порядке
+type Supervisor struct {
+	specs     map[string]ProcessSpec
+	order     []string
+	procs     map[string]*BackgroundProcess
+	cancels   map[string]context.CancelFunc
+	stopGrace time.Duration
+
+	m       sync.Mutex
+	log     []supervisedLine
+	dropped int
+}
+
+// supervisorLogLines максимальное количество последних строк вывода, сохраняемых супервизором
+const supervisorLogLines = 10000
+
+type supervisedLine struct {
+	name string
+	line OutputLine
+}
+
+// NewSupervisor возвращает новый супервизор для переданных процессов.
+// Возвращает ошибку при повторяющихся именах, неизвестных или циклических зависимостях
+func NewSupervisor(specs ...ProcessSpec) (*Supervisor, error) {
+	s := &Supervisor{
+		specs:     make(map[string]ProcessSpec, len(specs)),
+		procs:     make(map[string]*BackgroundProcess, len(specs)),
+		cancels:   make(map[string]context.CancelFunc, len(specs)),
+		stopGrace: 10 * time.Second,
+	}
+
+	for _, spec := range specs {
+		if _, ok := s.specs[spec.Name]; ok {
+			return nil, fmt.Errorf("duplicate process name %q", spec.Name)
+		}
+		s.specs[spec.Name] = spec
+	}
+
+	// топологическая сортировка с сохранением порядка объявления
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(specs))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		marks[name] = visiting
+		for _, dep := range s.specs[name].DependsOn {
+			if _, ok := s.specs[dep]; !ok {
+				return fmt.Errorf("process %q depends on unknown process %q", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		s.order = append(s.order, name)
+		return nil
+	}
+	for _, spec := range specs {
+		if err := visit(spec.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Start запускает процессы в порядке зависимостей, дожидаясь готовности каждого из них.
+// При ошибке текст ошибки содержит объединенные логи всех запущенных процессов.
+// Уже запущенные процессы необходимо остановить вызовом Stop
+func (s *Supervisor) Start(ctx context.Context) error {
+	for _, name := range s.order {
+		if _, ok := s.procs[name]; ok {
+			continue
+		}
+
+		spec := s.specs[name]
+		deps := make(map[string]*BackgroundProcess, len(spec.DependsOn))
+		for _, dep := range spec.DependsOn {
+			deps[dep] = s.procs[dep]
+		}
+		opts := append(slices.Clone(spec.Opts), withDeps(deps), WithLineHandler(func(line OutputLine) {
+			s.m.Lock()
+			defer s.m.Unlock()
+			if len(s.log) == supervisorLogLines {
+				s.log = append(s.log[:0], s.log[supervisorLogLines/2:]...)
+				s.dropped += supervisorLogLines / 2
+			}
+			s.log = append(s.log, supervisedLine{name: name, line: line})
+		}))
+
+		// контекст процесса отменяется в Stop, освобождая выделенные процессу порты
+		procCtx, cancel := context.WithCancel(context.Background())
+		s.cancels[name] = cancel
+
+		p := NewBackgroundProcess(procCtx, spec.Command, opts...)
+		if err := p.Start(ctx); err != nil {
+			return s.startError(name, fmt.Errorf("cannot start process %s: %w", p, err))
+		}
+		s.procs[name] = p
+
+		if err := p.WaitReady(ctx, spec.Ready...); err != nil {
+			return s.startError(name, fmt.Errorf("process is not ready: %w", err))
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) startError(name string, err error) error {
+	logs := s.Logs()
+	if logs == "" {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return fmt.Errorf("%s: %w\n\nprocesses output:\n%s", name, err, logs)
+}
+
+// Stop останавливает запущенные процессы в порядке, обратном порядку запуска,
+// и освобождает ресурсы всех процессов, включая не сумевшие запуститься
+func (s *Supervisor) Stop(ctx context.Context) (map[string]StopResult, error) {
+	results := make(map[string]StopResult, len(s.procs))
+
+	var errs []error
+	for _, name := range slices.Backward(s.order) {
+		if cancel, ok := s.cancels[name]; ok {
+			defer cancel()
+		}
+		p, ok := s.procs[name]
+		if !ok {
+			continue
+		}
+		res, err := p.StopGraceful(ctx, s.stopGrace)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		results[name] = res
+	}
+	return results, errors.Join(errs...)
+}
+
+// Process возвращает запущенный процесс по имени или nil, если процесс не был запущен
+func (s *Supervisor) Process(name string) *BackgroundProcess {
+	return s.procs[name]
+}
+
+// Logs возвращает вывод всех процессов в хронологическом порядке с указанием имени процесса
+func (s *Supervisor) Logs() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	var b strings.Builder
+	if s.dropped > 0 {
+		fmt.Fprintf(&b, "... пропущено %d строк вывода ...\n", s.dropped)
+	}
+	for _, l := range s.log {
+		fmt.Fprintf(&b, "%s %s [%s] %s\n", l.line.Time.Format("15:04:05.000"), l.name, l.line.Stream, l.line.Text)
+	}
+	return b.String()
+}
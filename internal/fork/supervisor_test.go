@@ -0,0 +1,157 @@
+//go:build !windows
+
+package fork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+func TestNewSupervisor(t *testing.T) {
+	s, err := NewSupervisor(
+		ProcessSpec{Name: "gophermart", DependsOn: []string{"accrual", "db"}},
+		ProcessSpec{Name: "accrual", DependsOn: []string{"db"}},
+		ProcessSpec{Name: "db"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"db", "accrual", "gophermart"}, s.order)
+
+	_, err = NewSupervisor(
+		ProcessSpec{Name: "a", DependsOn: []string{"b"}},
+		ProcessSpec{Name: "b", DependsOn: []string{"a"}},
+	)
+	require.ErrorContains(t, err, "dependency cycle: a -> b -> a")
+
+	_, err = NewSupervisor(ProcessSpec{Name: "a", DependsOn: []string{"c"}})
+	require.ErrorContains(t, err, "unknown process")
+
+	_, err = NewSupervisor(ProcessSpec{Name: "a"}, ProcessSpec{Name: "a"})
+	require.ErrorContains(t, err, "duplicate")
+}
+
+func TestSupervisor(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSupervisor(
+		ProcessSpec{
+			Name:      "second",
+			Command:   "sh",
+			Opts:      []ProcessOpt{WithArgs("-c", "echo second ready; exec sleep 10")},
+			DependsOn: []string{"first"},
+			Ready:     []ReadinessProbe{OutputProbe(regexp.MustCompile("ready"))},
+		},
+		ProcessSpec{
+			Name:    "first",
+			Command: "sh",
+			Opts:    []ProcessOpt{WithArgs("-c", "echo first ready; exec sleep 10")},
+			Ready:   []ReadinessProbe{OutputProbe(regexp.MustCompile("ready"))},
+		},
+		ProcessSpec{
+			Name:      "broken",
+			Command:   "sh",
+			Opts:      []ProcessOpt{WithArgs("-c", "echo broken 1>&2; exit 1")},
+			DependsOn: []string{"second"},
+			Ready:     []ReadinessProbe{OutputProbe(regexp.MustCompile("ready"))},
+		},
+	)
+	require.NoError(t, err)
+
+	err = s.Start(ctx)
+	require.ErrorContains(t, err, "broken: process is not ready")
+	require.ErrorContains(t, err, "first [stdout] first ready")
+	require.ErrorContains(t, err, "second [stdout] second ready")
+	require.ErrorContains(t, err, "broken [stderr] broken")
+
+	results, err := s.Stop(ctx)
+	require.NoError(t, err)
+	require.Equal(t, StopStageExited, results["broken"].Stage)
+	require.Equal(t, StopStageSignal, results["first"].Stage)
+	require.Equal(t, StopStageSignal, results["second"].Stage)
+}
+
+func TestSupervisorDependencyPorts(t *testing.T) {
+	t.Setenv(random.PortLockDirEnv, t.TempDir())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSupervisor(
+		ProcessSpec{
+			Name:    "client",
+			Command: "sh",
+			Opts: []ProcessOpt{
+				WithArgs("-c", `echo "server at $SERVER_ADDRESS"; exec sleep 10`),
+				WithEnv("SERVER_ADDRESS=http://{{.Deps.server.Addrs.http}}"),
+			},
+			DependsOn: []string{"server"},
+			Ready:     []ReadinessProbe{OutputProbe(regexp.MustCompile("server at"))},
+		},
+		ProcessSpec{
+			Name:    "server",
+			Command: "sh",
+			Opts: []ProcessOpt{
+				WithAutoPort("http"),
+				WithArgs("-c", `echo "listening on $1"; exec sleep 10`, "sh", "{{.Ports.http}}"),
+			},
+			Ready: []ReadinessProbe{OutputProbe(regexp.MustCompile("listening"))},
+		},
+	)
+	require.NoError(t, err)
+	defer s.Stop(ctx)
+	require.NoError(t, s.Start(ctx))
+
+	port := s.Process("server").Port("http")
+	require.Positive(t, port)
+	require.Contains(t, s.Logs(), fmt.Sprintf("client [stdout] server at http://localhost:%d", port))
+
+	broken, err := NewSupervisor(ProcessSpec{
+		Name:    "client",
+		Command: "sh",
+		Opts:    []ProcessOpt{WithArgs("{{.Deps.server.Ports.http}}")},
+	})
+	require.NoError(t, err)
+	defer broken.Stop(ctx)
+	require.ErrorContains(t, broken.Start(ctx), "cannot render template")
+}
+
+func TestSupervisorReleasesPorts(t *testing.T) {
+	t.Setenv(random.PortLockDirEnv, t.TempDir())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s, err := NewSupervisor(ProcessSpec{
+		Name:    "server",
+		Command: "sh",
+		Opts:    []ProcessOpt{WithAutoPort("server"), WithArgs("-c", "echo ready; exec sleep 10")},
+		Ready:   []ReadinessProbe{OutputProbe(regexp.MustCompile("ready"))},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Start(ctx))
+
+	lockPath := filepath.Join(random.PortLockDir(), strconv.Itoa(s.Process("server").Port("server"))+".lock")
+	tryLock := func() error {
+		f, err := os.Open(lockPath)
+		require.NoError(t, err)
+		defer f.Close()
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	}
+	require.Error(t, tryLock(), "port must be locked while process is running")
+
+	_, err = s.Stop(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return tryLock() == nil }, time.Second, 10*time.Millisecond,
+		"port lock must be released after Stop")
+}
@@ -0,0 +1,222 @@
+package logscan
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+)
+
+// Collector накапливает структурированные записи из вывода процесса.
+// Подключается к процессу опцией fork.WithLineHandler(c.Handle)
+type Collector struct {
+	m       sync.Mutex
+	entries []Entry
+	lines   int
+}
+
+// NewCollector возвращает новый пустой Collector
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Handle распознает строку вывода процесса и сохраняет ее, если она является структурированной записью лога
+func (c *Collector) Handle(line fork.OutputLine) {
+	e, ok := Parse(line.Text)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.lines++
+	if !ok {
+		return
+	}
+	e.Time = line.Time
+	c.entries = append(c.entries, e)
+}
+
+// Entries возвращает копию всех распознанных записей
+func (c *Collector) Entries() []Entry {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Since возвращает записи, полученные не раньше момента t
+func (c *Collector) Since(t time.Time) []Entry {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	var res []Entry
+	for _, e := range c.entries {
+		if !e.Time.Before(t) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Lines возвращает общее количество полученных строк вывода, включая нераспознанные
+func (c *Collector) Lines() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.lines
+}
+
+// RequestRecord содержит сведения о запросе, найденные в записях лога
+type RequestRecord struct {
+	// Fields значения найденных сведений о запросе
+	Fields map[RequestField]string
+	// Entries записи лога, из которых собраны сведения
+	Entries []Entry
+}
+
+// Missing возвращает сведения о запросе, которые не были найдены в логе
+func (r RequestRecord) Missing() []RequestField {
+	var missing []RequestField
+	for _, f := range RequestFields {
+		if _, ok := r.Fields[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// Status возвращает записанный в лог код статуса ответа
+func (r RequestRecord) Status() (int, bool) {
+	return leadingInt(r.Fields[FieldStatus])
+}
+
+// Size возвращает записанный в лог размер содержимого ответа в байтах
+func (r RequestRecord) Size() (int, bool) {
+	return leadingInt(r.Fields[FieldSize])
+}
+
+// leadingInt разбирает целое число в начале строки, допуская единицы измерения после него, например 19B
+func leadingInt(s string) (int, bool) {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[:end])
+	return n, err == nil
+}
+
+// String возвращает найденные записи лога в виде, пригодном для сообщений об ошибках
+func (r RequestRecord) String() string {
+	var b strings.Builder
+	for _, e := range r.Entries {
+		b.WriteString(e.Raw)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Correlate собирает сведения о запросе method uri из последовательности записей лога.
+// Логгеры часто пишут сведения о запросе и об ответе отдельными записями,
+// поэтому к записи, содержащей URI запроса, присоединяются соседние записи
+// вплоть до записи о другом URI. Возвращает false, если запись с URI не найдена
+func Correlate(entries []Entry, method, uri string) (RequestRecord, bool) {
+	anchor := -1
+	for i, e := range entries {
+		if mentionsURI(e, uri) && mentionsMethod(e, method) {
+			anchor = i
+			break
+		}
+	}
+	if anchor < 0 {
+		return RequestRecord{}, false
+	}
+
+	// определяем границы группы записей, относящихся к запросу
+	start := anchor
+	for start > 0 && !mentionsOtherURI(entries[start-1], uri) {
+		start--
+	}
+	end := anchor + 1
+	for end < len(entries) && !mentionsOtherURI(entries[end], uri) {
+		end++
+	}
+
+	rec := RequestRecord{Fields: make(map[RequestField]string)}
+	// запись с URI имеет приоритет, затем учитываются последующие и предшествующие записи
+	group := append([]Entry{entries[anchor]}, entries[anchor+1:end]...)
+	before := slices.Clone(entries[start:anchor])
+	slices.Reverse(before)
+	group = append(group, before...)
+	for _, e := range group {
+		used := false
+		for _, f := range RequestFields {
+			if _, ok := rec.Fields[f]; ok {
+				continue
+			}
+			if v, ok := e.Field(f); ok {
+				rec.Fields[f] = v
+				used = true
+			}
+		}
+		if used {
+			rec.Entries = append(rec.Entries, e)
+		}
+	}
+	return rec, true
+}
+
+// WaitRequest ожидает появления в логе полных сведений о запросе method uri,
+// выполненном не раньше момента since. При истечении контекста возвращает
+// частично собранные сведения вместе с ошибкой
+func (c *Collector) WaitRequest(ctx context.Context, since time.Time, method, uri string) (RequestRecord, error) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		rec, found := Correlate(c.Since(since), method, uri)
+		if found && len(rec.Missing()) == 0 {
+			return rec, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if !found {
+				return rec, fmt.Errorf("no structured log entry for request %s %s: %w", method, uri, ctx.Err())
+			}
+			return rec, fmt.Errorf("log entries for request %s %s miss fields %v: %w", method, uri, rec.Missing(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func mentionsURI(e Entry, uri string) bool {
+	v, ok := e.Field(FieldURI)
+	if !ok {
+		return false
+	}
+	if v == uri {
+		return true
+	}
+	// в лог может попасть полный URL или URI вместе с параметрами запроса
+	u, err := url.Parse(v)
+	return err == nil && (u.RequestURI() == uri || u.Path == uri)
+}
+
+func mentionsMethod(e Entry, method string) bool {
+	if method == "" {
+		return true
+	}
+	v, ok := e.Field(FieldMethod)
+	// метод может быть записан отдельной записью
+	return !ok || strings.EqualFold(v, method)
+}
+
+func mentionsOtherURI(e Entry, uri string) bool {
+	_, ok := e.Field(FieldURI)
+	return ok && !mentionsURI(e, uri)
+}
@@ -0,0 +1,84 @@
+package logscan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/fork"
+)
+
+func TestCorrelate(t *testing.T) {
+	c := NewCollector()
+	for _, line := range []string{
+		"server started",
+		`{"level":"info","msg":"request","method":"GET","uri":"/value/gauge/a","duration":"1ms"}`,
+		`{"level":"info","msg":"response","status":404,"size":19}`,
+		`{"level":"info","msg":"request","method":"POST","uri":"/update/gauge/a/1","duration":"2ms"}`,
+		`{"level":"info","msg":"response","status":200,"size":0}`,
+	} {
+		c.Handle(fork.OutputLine{Stream: fork.StreamStderr, Time: time.Now(), Text: line})
+	}
+	assert.Equal(t, 5, c.Lines())
+	assert.Len(t, c.Entries(), 4)
+
+	rec, ok := Correlate(c.Entries(), "POST", "/update/gauge/a/1")
+	require.True(t, ok)
+	assert.Empty(t, rec.Missing())
+	assert.Equal(t, "200", rec.Fields[FieldStatus])
+	assert.Equal(t, "2ms", rec.Fields[FieldDuration])
+	assert.Len(t, rec.Entries, 2)
+
+	rec, ok = Correlate(c.Entries(), "GET", "/value/gauge/a")
+	require.True(t, ok)
+	assert.Empty(t, rec.Missing())
+	assert.Equal(t, "404", rec.Fields[FieldStatus])
+
+	_, ok = Correlate(c.Entries(), "GET", "/value/counter/a")
+	assert.False(t, ok)
+}
+
+func TestCollectorWaitRequest(t *testing.T) {
+	c := NewCollector()
+	since := time.Now()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.Handle(fork.OutputLine{Time: time.Now(), Text: `method=GET uri=/ status=307 duration=10µs size=0`})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rec, err := c.WaitRequest(ctx, since, "GET", "/")
+	require.NoError(t, err)
+	assert.Equal(t, "307", rec.Fields[FieldStatus])
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c.Handle(fork.OutputLine{Time: time.Now(), Text: `method=POST uri=/api/shorten`})
+	rec, err = c.WaitRequest(ctx, since, "POST", "/api/shorten")
+	require.Error(t, err)
+	assert.Equal(t, []RequestField{FieldStatus, FieldDuration, FieldSize}, rec.Missing())
+}
+
+func TestRequestRecordNumbers(t *testing.T) {
+	rec := RequestRecord{Fields: map[RequestField]string{
+		FieldStatus: "404",
+		FieldSize:   "19B",
+	}}
+
+	status, ok := rec.Status()
+	require.True(t, ok)
+	assert.Equal(t, 404, status)
+
+	size, ok := rec.Size()
+	require.True(t, ok)
+	assert.Equal(t, 19, size)
+
+	rec.Fields[FieldSize] = "unknown"
+	_, ok = rec.Size()
+	assert.False(t, ok)
+}
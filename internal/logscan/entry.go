@@ -0,0 +1,242 @@
+// Package logscan распознает структурированные записи логов тестируемых процессов
+// и позволяет проверять, какие сведения о запросах в них попадают
+package logscan
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Format обозначает формат записи лога
+type Format string
+
+const (
+	// FormatJSON запись в формате JSON (zap production, zerolog, logrus JSONFormatter, slog JSONHandler)
+	FormatJSON Format = "json"
+	// FormatConsole текстовая запись с полями в формате JSON в конце строки (zap development)
+	FormatConsole Format = "console"
+	// FormatLogfmt запись в формате key=value (logrus TextFormatter, slog TextHandler, zerolog ConsoleWriter)
+	FormatLogfmt Format = "logfmt"
+)
+
+// Entry является распознанной структурированной записью лога
+type Entry struct {
+	// Time время получения записи
+	Time time.Time
+	// Format формат записи
+	Format Format
+	// Fields поля записи, вложенные объекты разворачиваются в ключи через точку
+	Fields map[string]string
+	// Raw исходная строка лога
+	Raw string
+}
+
+// String возвращает исходную строку лога
+func (e Entry) String() string {
+	return e.Raw
+}
+
+// messageKeys ключи, под которыми логгеры сохраняют текст сообщения
+var messageKeys = []string{"msg", "message"}
+
+// Parse распознает строку лога в одном из поддерживаемых форматов
+func Parse(line string) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Entry{}, false
+	}
+
+	e := Entry{Raw: line}
+
+	// JSON запись целиком
+	if strings.HasPrefix(line, "{") {
+		if fields, ok := parseJSON(line); ok {
+			e.Format = FormatJSON
+			e.Fields = fields
+			e.addMessagePairs()
+			return e, true
+		}
+	}
+
+	// текстовая запись с JSON объектом полей в конце строки
+	if idx := strings.Index(line, "\t{"); idx >= 0 {
+		if fields, ok := parseJSON(line[idx+1:]); ok {
+			e.Format = FormatConsole
+			e.Fields = fields
+			e.Fields["msg"] = strings.TrimSpace(line[:idx])
+			e.addMessagePairs()
+			return e, true
+		}
+	}
+
+	// запись в формате key=value
+	if fields := parseLogfmt(line); len(fields) >= 2 {
+		e.Format = FormatLogfmt
+		e.Fields = fields
+		e.addMessagePairs()
+		return e, true
+	}
+
+	return Entry{}, false
+}
+
+// parseJSON разбирает JSON объект и разворачивает вложенные объекты в плоский набор полей
+func parseJSON(s string) (map[string]string, bool) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(obj))
+	var flatten func(prefix string, v any)
+	flatten = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, vv := range v {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				flatten(key, vv)
+			}
+		case nil:
+			fields[prefix] = ""
+		case string:
+			fields[prefix] = v
+		case json.Number:
+			fields[prefix] = v.String()
+		default:
+			b, _ := json.Marshal(v)
+			fields[prefix] = string(b)
+		}
+	}
+	flatten("", obj)
+	return fields, true
+}
+
+// parseLogfmt находит в строке все пары вида key=value и key="quoted value"
+func parseLogfmt(s string) map[string]string {
+	fields := make(map[string]string)
+
+	for i := 0; i < len(s); {
+		// пропускаем разделители
+		for i < len(s) && s[i] == ' ' || i < len(s) && s[i] == '\t' {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' && s[i] != '\t' {
+			i++
+		}
+		if i >= len(s) || s[i] != '=' || i == start {
+			// не пара ключ-значение, переходим к следующему токену
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				i++
+			}
+			continue
+		}
+		key := s[start:i]
+		i++ // пропускаем '='
+
+		var value string
+		if i < len(s) && s[i] == '"' {
+			j := i + 1
+			var b strings.Builder
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+				j++
+			}
+			value = b.String()
+			i = j + 1
+		} else {
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+				j++
+			}
+			value = s[i:j]
+			i = j
+		}
+		fields[stripColor(key)] = stripColor(value)
+	}
+	return fields
+}
+
+// stripColor удаляет ANSI последовательности, которыми консольные логгеры раскрашивают вывод
+func stripColor(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// addMessagePairs дополняет поля записи парами "ключ значение" из текста сообщения,
+// так как сведения о запросе часто передаются строкой, например через zap.SugaredLogger.Infoln
+func (e *Entry) addMessagePairs() {
+	for _, mk := range messageKeys {
+		msg, ok := e.Fields[mk]
+		if !ok {
+			continue
+		}
+		for k, v := range parseLogfmt(msg) {
+			if _, exists := e.Fields[k]; !exists {
+				e.Fields[k] = v
+			}
+		}
+		tokens := strings.FieldsFunc(msg, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ',' || r == ';'
+		})
+		for i := 0; i+1 < len(tokens); i++ {
+			key := strings.TrimRight(tokens[i], ":")
+			if _, known := lookupField(key); !known {
+				continue
+			}
+			if _, exists := e.Fields[key]; !exists {
+				e.Fields[key] = tokens[i+1]
+			}
+		}
+	}
+}
+
+// Field возвращает значение поля записи, соответствующего одному из известных имен,
+// используемых логгерами для сведений о запросах
+func (e Entry) Field(field RequestField) (string, bool) {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		if f, ok := lookupField(key); ok && f == field {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+
+	// предпочитаем ключи верхнего уровня, чтобы результат не зависел от порядка обхода полей
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(strings.Count(a, "."), strings.Count(b, ".")); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	return e.Fields[keys[0]], true
+}
@@ -0,0 +1,118 @@
+package logscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		format Format
+		want   map[RequestField]string
+	}{
+		{
+			name:   "zap_production",
+			line:   `{"level":"info","ts":1700000000.123,"caller":"logger/logger.go:42","msg":"got incoming HTTP request","method":"POST","uri":"/update/counter/a/1","duration":0.000123,"status":200,"size":0}`,
+			format: FormatJSON,
+			want: map[RequestField]string{
+				FieldMethod: "POST", FieldURI: "/update/counter/a/1", FieldStatus: "200",
+				FieldDuration: "0.000123", FieldSize: "0",
+			},
+		},
+		{
+			name:   "zap_development",
+			line:   "2024-01-01T00:00:00.000+0300\tINFO\tlogger/logger.go:42\trequest\t{\"method\": \"GET\", \"uri\": \"/value/gauge/a\", \"duration\": \"1.5ms\"}",
+			format: FormatConsole,
+			want: map[RequestField]string{
+				FieldMethod: "GET", FieldURI: "/value/gauge/a", FieldDuration: "1.5ms",
+			},
+		},
+		{
+			name:   "zap_sugared_message",
+			line:   `{"level":"info","msg":"uri /value/gauge/a method GET duration 15.2µs"}`,
+			format: FormatJSON,
+			want: map[RequestField]string{
+				FieldMethod: "GET", FieldURI: "/value/gauge/a", FieldDuration: "15.2µs",
+			},
+		},
+		{
+			name:   "zerolog_nested",
+			line:   `{"level":"info","request":{"method":"GET","url":"/"},"response":{"status_code":307,"bytes_written":42},"elapsed_ms":3,"time":"2024-01-01T00:00:00Z"}`,
+			format: FormatJSON,
+			want: map[RequestField]string{
+				FieldMethod: "GET", FieldURI: "/", FieldStatus: "307",
+				FieldDuration: "3", FieldSize: "42",
+			},
+		},
+		{
+			name:   "logrus_text",
+			line:   `time="2024-01-01T00:00:00Z" level=info msg="request completed" method=GET path="/api/shorten" latency=1.2ms status=201 content_length=30`,
+			format: FormatLogfmt,
+			want: map[RequestField]string{
+				FieldMethod: "GET", FieldURI: "/api/shorten", FieldStatus: "201",
+				FieldDuration: "1.2ms", FieldSize: "30",
+			},
+		},
+		{
+			name:   "ambiguous_keys",
+			line:   `{"level":"error","msg":"insert failed","code":"23505","target":"orders","length":12}`,
+			format: FormatJSON,
+			want:   map[RequestField]string{},
+		},
+		{
+			name:   "nested_code",
+			line:   `level=info msg=request http.method=GET http.target=/ping response.code=200`,
+			format: FormatLogfmt,
+			want: map[RequestField]string{
+				FieldMethod: "GET", FieldURI: "/ping", FieldStatus: "200",
+			},
+		},
+		{
+			name:   "slog_text",
+			line:   `time=2024-01-01T00:00:00.000Z level=INFO msg=response http.status=404 http.size=19`,
+			format: FormatLogfmt,
+			want: map[RequestField]string{
+				FieldStatus: "404", FieldSize: "19",
+			},
+		},
+		{
+			name:   "colored_console",
+			line:   "\x1b[90m10:00AM\x1b[0m \x1b[32mINF\x1b[0m request \x1b[36mmethod=\x1b[0mPOST \x1b[36muri=\x1b[0m/update/",
+			format: FormatLogfmt,
+			want: map[RequestField]string{
+				FieldMethod: "POST", FieldURI: "/update/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := Parse(tt.line)
+			require.True(t, ok)
+			assert.Equal(t, tt.format, e.Format)
+
+			for _, f := range RequestFields {
+				v, ok := e.Field(f)
+				want, wantOK := tt.want[f]
+				assert.Equal(t, wantOK, ok, "field %s", f)
+				assert.Equal(t, want, v, "field %s", f)
+			}
+		})
+	}
+}
+
+func TestParseUnstructured(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"server started",
+		"2024/01/01 00:00:00 listening on localhost:8080",
+		"{not a json",
+	} {
+		_, ok := Parse(line)
+		assert.False(t, ok, line)
+	}
+}
@@ -0,0 +1,104 @@
+package logscan
+
+import (
+	"strings"
+)
+
+// RequestField обозначает сведения о запросе, которые должны попадать в лог
+type RequestField string
+
+const (
+	// FieldMethod метод запроса
+	FieldMethod RequestField = "method"
+	// FieldURI URI запроса
+	FieldURI RequestField = "uri"
+	// FieldStatus код статуса ответа
+	FieldStatus RequestField = "status"
+	// FieldDuration время, затраченное на выполнение запроса
+	FieldDuration RequestField = "duration"
+	// FieldSize размер содержимого ответа
+	FieldSize RequestField = "size"
+)
+
+// RequestFields перечисляет все сведения о запросе в порядке их проверки
+var RequestFields = []RequestField{FieldMethod, FieldURI, FieldStatus, FieldDuration, FieldSize}
+
+// fieldAliases содержит распространенные имена полей логов в нормализованном виде.
+// Общие имена вроде code, target или length сюда не входят: они часто встречаются
+// в логах ошибок и бизнес-событий и не означают сведений о запросе
+var fieldAliases = map[string]RequestField{
+	"method":        FieldMethod,
+	"httpmethod":    FieldMethod,
+	"reqmethod":     FieldMethod,
+	"requestmethod": FieldMethod,
+	"verb":          FieldMethod,
+
+	"uri":         FieldURI,
+	"url":         FieldURI,
+	"path":        FieldURI,
+	"requesturi":  FieldURI,
+	"requrl":      FieldURI,
+	"requri":      FieldURI,
+	"requesturl":  FieldURI,
+	"requestpath": FieldURI,
+	"httptarget":  FieldURI,
+
+	"status":         FieldStatus,
+	"statuscode":     FieldStatus,
+	"responsecode":   FieldStatus,
+	"httpcode":       FieldStatus,
+	"httpstatus":     FieldStatus,
+	"respstatus":     FieldStatus,
+	"responsestatus": FieldStatus,
+
+	"duration":      FieldDuration,
+	"durationms":    FieldDuration,
+	"durationns":    FieldDuration,
+	"latency":       FieldDuration,
+	"latencyms":     FieldDuration,
+	"elapsed":       FieldDuration,
+	"elapsedms":     FieldDuration,
+	"elapsedtime":   FieldDuration,
+	"took":          FieldDuration,
+	"responsetime":  FieldDuration,
+	"executiontime": FieldDuration,
+	"timetaken":     FieldDuration,
+
+	"size":          FieldSize,
+	"sizebytes":     FieldSize,
+	"bytes":         FieldSize,
+	"byteswritten":  FieldSize,
+	"bytesout":      FieldSize,
+	"written":       FieldSize,
+	"contentlength": FieldSize,
+	"bodysize":      FieldSize,
+	"respsize":      FieldSize,
+	"responsesize":  FieldSize,
+	"responsebytes": FieldSize,
+}
+
+// lookupField сопоставляет ключ поля лога со сведениями о запросе.
+// Для вложенных ключей учитывается как ключ целиком, так и его последний сегмент
+func lookupField(key string) (RequestField, bool) {
+	if f, ok := fieldAliases[normalizeKey(key)]; ok {
+		return f, true
+	}
+	if idx := strings.LastIndexByte(key, '.'); idx >= 0 {
+		f, ok := fieldAliases[normalizeKey(key[idx+1:])]
+		return f, ok
+	}
+	return "", false
+}
+
+// normalizeKey приводит ключ к нижнему регистру и удаляет разделители слов
+func normalizeKey(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(key) {
+		switch r {
+		case '_', '-', '.', ' ':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
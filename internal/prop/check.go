@@ -0,0 +1,160 @@
+package prop
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+// Option настраивает проверку свойства
+type Option func(*config)
+
+type config struct {
+	runs       int
+	maxShrinks int
+	rnd        *rand.Rand
+}
+
+// WithRuns задает количество проверяемых случайных значений, по умолчанию 100
+func WithRuns(n int) Option {
+	return func(c *config) {
+		c.runs = n
+	}
+}
+
+// WithMaxShrinks ограничивает количество проверок свойства при поиске минимального контрпримера, по умолчанию 1000
+func WithMaxShrinks(n int) Option {
+	return func(c *config) {
+		c.maxShrinks = n
+	}
+}
+
+// WithRand задает генератор псевдослучайных чисел
+func WithRand(r *rand.Rand) Option {
+	return func(c *config) {
+		c.rnd = r
+	}
+}
+
+// Failure описывает найденный контрпример
+type Failure[T any] struct {
+	// Run является номером проверки, на которой найден контрпример
+	Run int
+	// Original является первым найденным контрпримером
+	Original T
+	// Minimal является минимальным найденным контрпримером
+	Minimal T
+	// Err является ошибкой проверки минимального контрпримера
+	Err error
+	// Shrinks является количеством успешных уменьшений контрпримера
+	Shrinks int
+}
+
+// String реализует интерфейс fmt.Stringer
+func (f *Failure[T]) String() string {
+	return fmt.Sprintf("минимальный контрпример: %s\nошибка: %s\nисходный контрпример: %s\nнайден на проверке %d, уменьшен %d раз",
+		format(f.Minimal), f.Err, format(f.Original), f.Run, f.Shrinks)
+}
+
+// ForAll проверяет свойство prop на значениях генератора g.
+// Свойство сообщает о нарушении, возвращая ошибку, и не должно вызывать проверки testify,
+// так как во время уменьшения контрпримера оно вызывается на заведомо некорректных значениях.
+// Возвращает nil, если свойство выполнилось для всех значений
+func ForAll[T any](g Gen[T], prop func(T) error, opts ...Option) *Failure[T] {
+	cfg := config{
+		runs:       100,
+		maxShrinks: 1000,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.rnd == nil {
+		cfg.rnd = random.NewRand("prop")
+	}
+
+	for run := 0; run < cfg.runs; run++ {
+		size := MaxSize
+		if cfg.runs > 1 {
+			size = run * MaxSize / (cfg.runs - 1)
+		}
+
+		t := g(cfg.rnd, size)
+		err := call(prop, t.Value)
+		if err == nil {
+			continue
+		}
+
+		f := &Failure[T]{
+			Run:      run + 1,
+			Original: t.Value,
+			Minimal:  t.Value,
+			Err:      err,
+		}
+		shrink(f, t, prop, cfg.maxShrinks)
+		return f
+	}
+	return nil
+}
+
+// Check проверяет свойство prop на значениях генератора g и сообщает в t о минимальном контрпримере.
+// По умолчанию генератор псевдослучайных чисел зависит от имени теста и значения -random-seed
+func Check[T any](t testing.TB, g Gen[T], prop func(T) error, opts ...Option) bool {
+	t.Helper()
+
+	opts = append([]Option{WithRand(random.NewRand(t.Name()))}, opts...)
+	f := ForAll(g, prop, opts...)
+	if f == nil {
+		return true
+	}
+	t.Errorf("Свойство нарушено, %s", f)
+	return false
+}
+
+// shrink жадно спускается по дереву уменьшений, пока находятся нарушающие свойство значения
+func shrink[T any](f *Failure[T], t Tree[T], prop func(T) error, maxCalls int) {
+	calls := 0
+	for {
+		found := false
+		for _, c := range t.Shrinks() {
+			if calls >= maxCalls {
+				return
+			}
+			calls++
+
+			if err := call(prop, c.Value); err != nil {
+				t = c
+				f.Minimal = c.Value
+				f.Err = err
+				f.Shrinks++
+				found = true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+}
+
+// call вызывает свойство, превращая панику в ошибку
+func call[T any](prop func(T) error, v T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника: %v", r)
+		}
+	}()
+	return prop(v)
+}
+
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%+v", v)
+}
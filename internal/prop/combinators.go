@@ -0,0 +1,164 @@
+package prop
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Pair является парой значений, сгенерированных Zip
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Map преобразует значения генератора функцией f, сохраняя возможность уменьшения
+func Map[T, U any](g Gen[T], f func(T) U) Gen[U] {
+	return func(r *rand.Rand, size int) Tree[U] {
+		return mapTree(g(r, size), f)
+	}
+}
+
+// Filter оставляет только значения, удовлетворяющие условию pred.
+// Если за 100 попыток подходящее значение не найдено, генератор паникует
+func Filter[T any](g Gen[T], pred func(T) bool) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		for i := 0; i < 100; i++ {
+			t := g(r, size)
+			if pred(t.Value) {
+				return filterTree(t, pred)
+			}
+		}
+		panic("prop: не удалось сгенерировать значение, удовлетворяющее условию фильтра")
+	}
+}
+
+// Zip объединяет два генератора. Сначала уменьшается первое значение, затем второе
+func Zip[A, B any](a Gen[A], b Gen[B]) Gen[Pair[A, B]] {
+	return func(r *rand.Rand, size int) Tree[Pair[A, B]] {
+		return zipTree(a(r, size), b(r, size))
+	}
+}
+
+// OneOf генерирует значение одним из случайно выбранных генераторов
+func OneOf[T any](gens ...Gen[T]) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return gens[r.Intn(len(gens))](r, size)
+	}
+}
+
+// SliceOf генерирует срезы длиной от minLen до maxLen. Длина растет вместе с параметром size.
+// Срез уменьшается удалением элементов, затем уменьшением каждого элемента
+func SliceOf[T any](g Gen[T], minLen, maxLen int) Gen[[]T] {
+	return func(r *rand.Rand, size int) Tree[[]T] {
+		limit := minLen + (maxLen-minLen)*size/MaxSize
+		n := minLen
+		if limit > minLen {
+			n += r.Intn(limit - minLen + 1)
+		}
+
+		trees := make([]Tree[T], 0, n)
+		for i := 0; i < n; i++ {
+			trees = append(trees, g(r, size))
+		}
+		return sliceTree(trees, minLen)
+	}
+}
+
+// SubsetOf генерирует подмножество переданных элементов с сохранением порядка.
+// Подмножество уменьшается удалением элементов
+func SubsetOf[T any](xs ...T) Gen[[]T] {
+	return func(r *rand.Rand, _ int) Tree[[]T] {
+		var trees []Tree[T]
+		for _, x := range xs {
+			if r.Intn(2) == 0 {
+				trees = append(trees, Leaf(x))
+			}
+		}
+		return sliceTree(trees, 0)
+	}
+}
+
+func mapTree[T, U any](t Tree[T], f func(T) U) Tree[U] {
+	return Tree[U]{
+		Value: f(t.Value),
+		shrink: func() []Tree[U] {
+			children := t.Shrinks()
+			res := make([]Tree[U], 0, len(children))
+			for _, c := range children {
+				res = append(res, mapTree(c, f))
+			}
+			return res
+		},
+	}
+}
+
+func filterTree[T any](t Tree[T], pred func(T) bool) Tree[T] {
+	return Tree[T]{
+		Value: t.Value,
+		shrink: func() []Tree[T] {
+			var res []Tree[T]
+			for _, c := range t.Shrinks() {
+				if pred(c.Value) {
+					res = append(res, filterTree(c, pred))
+				}
+			}
+			return res
+		},
+	}
+}
+
+func zipTree[A, B any](a Tree[A], b Tree[B]) Tree[Pair[A, B]] {
+	return Tree[Pair[A, B]]{
+		Value: Pair[A, B]{First: a.Value, Second: b.Value},
+		shrink: func() []Tree[Pair[A, B]] {
+			var res []Tree[Pair[A, B]]
+			for _, c := range a.Shrinks() {
+				res = append(res, zipTree(c, b))
+			}
+			for _, c := range b.Shrinks() {
+				res = append(res, zipTree(a, c))
+			}
+			return res
+		},
+	}
+}
+
+func sliceTree[T any](trees []Tree[T], minLen int) Tree[[]T] {
+	values := make([]T, 0, len(trees))
+	for _, t := range trees {
+		values = append(values, t.Value)
+	}
+
+	return Tree[[]T]{
+		Value: values,
+		shrink: func() []Tree[[]T] {
+			var res []Tree[[]T]
+
+			// удаляем части среза, начиная с самых больших
+			for k := len(trees) - minLen; k > 0; k /= 2 {
+				for i := 0; i+k <= len(trees); i += k {
+					rest := make([]Tree[T], 0, len(trees)-k)
+					rest = append(rest, trees[:i]...)
+					rest = append(rest, trees[i+k:]...)
+					res = append(res, sliceTree(rest, minLen))
+				}
+			}
+
+			// уменьшаем элементы по одному
+			for i, t := range trees {
+				for _, c := range t.Shrinks() {
+					next := make([]Tree[T], len(trees))
+					copy(next, trees)
+					next[i] = c
+					res = append(res, sliceTree(next, minLen))
+				}
+			}
+			return res
+		},
+	}
+}
+
+// String реализует интерфейс fmt.Stringer
+func (p Pair[A, B]) String() string {
+	return fmt.Sprintf("(%s, %s)", format(p.First), format(p.Second))
+}
@@ -0,0 +1,176 @@
+package prop
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Tree является значением, сгенерированным генератором, вместе с ленивым деревом его уменьшений
+type Tree[T any] struct {
+	Value  T
+	shrink func() []Tree[T]
+}
+
+// Shrinks возвращает варианты уменьшения значения, начиная с наиболее простых
+func (t Tree[T]) Shrinks() []Tree[T] {
+	if t.shrink == nil {
+		return nil
+	}
+	return t.shrink()
+}
+
+// Gen генерирует значение типа T. Параметр size от 0 до MaxSize задает сложность значения
+type Gen[T any] func(r *rand.Rand, size int) Tree[T]
+
+// MaxSize является максимальной сложностью генерируемых значений
+const MaxSize = 100
+
+// Sample возвращает одно значение генератора
+func (g Gen[T]) Sample(r *rand.Rand) T {
+	return g(r, MaxSize).Value
+}
+
+// Leaf возвращает дерево значения без уменьшений
+func Leaf[T any](v T) Tree[T] {
+	return Tree[T]{Value: v}
+}
+
+// Const генерирует всегда одно и то же значение
+func Const[T any](v T) Gen[T] {
+	return func(*rand.Rand, int) Tree[T] {
+		return Leaf(v)
+	}
+}
+
+// From превращает обычный генератор в генератор без уменьшений
+func From[T any](f func(r *rand.Rand) T) Gen[T] {
+	return func(r *rand.Rand, _ int) Tree[T] {
+		return Leaf(f(r))
+	}
+}
+
+// Int64 генерирует числа в диапазоне [min, max], уменьшая их к ближайшему к нулю значению диапазона.
+// С небольшой вероятностью возвращает границы диапазона
+func Int64(min, max int64) Gen[int64] {
+	origin := int64(0)
+	switch {
+	case min > 0:
+		origin = min
+	case max < 0:
+		origin = max
+	}
+
+	return func(r *rand.Rand, size int) Tree[int64] {
+		var v int64
+		switch r.Intn(10) {
+		case 0:
+			v = min
+		case 1:
+			v = max
+		default:
+			// размах диапазона может не поместиться в int64
+			span := uint64(max) - uint64(min)
+			if span == math.MaxUint64 {
+				v = int64(r.Uint64())
+			} else {
+				v = int64(uint64(min) + r.Uint64()%(span+1))
+			}
+		}
+		return intTree(v, origin)
+	}
+}
+
+// Int генерирует числа в диапазоне [min, max]
+func Int(min, max int) Gen[int] {
+	return Map(Int64(int64(min), int64(max)), func(v int64) int {
+		return int(v)
+	})
+}
+
+// Float64 генерирует числа в диапазоне [min, max], уменьшая их к ближайшему к нулю значению диапазона
+func Float64(min, max float64) Gen[float64] {
+	origin := 0.0
+	switch {
+	case min > 0:
+		origin = min
+	case max < 0:
+		origin = max
+	}
+
+	return func(r *rand.Rand, size int) Tree[float64] {
+		v := min + r.Float64()*(max-min)
+		return floatTree(v, origin)
+	}
+}
+
+// Elements генерирует один из переданных элементов, уменьшая его к первому
+func Elements[T any](xs ...T) Gen[T] {
+	return Map(Int(0, len(xs)-1), func(i int) T {
+		return xs[i]
+	})
+}
+
+// Bool генерирует логические значения, уменьшая их к false
+func Bool() Gen[bool] {
+	return Elements(false, true)
+}
+
+// StringOf генерирует строки из символов алфавита длиной от minLen до maxLen
+func StringOf(alphabet string, minLen, maxLen int) Gen[string] {
+	return Map(SliceOf(Elements([]rune(alphabet)...), minLen, maxLen), func(rs []rune) string {
+		return string(rs)
+	})
+}
+
+func intTree(v, origin int64) Tree[int64] {
+	return Tree[int64]{
+		Value: v,
+		shrink: func() []Tree[int64] {
+			var res []Tree[int64]
+			for _, c := range towards(v, origin) {
+				res = append(res, intTree(c, origin))
+			}
+			return res
+		},
+	}
+}
+
+// towards возвращает значения между origin и v, приближающиеся к v бинарным поиском
+func towards(v, origin int64) []int64 {
+	if v == origin {
+		return nil
+	}
+
+	res := []int64{origin}
+	if v > origin {
+		for d := (uint64(v) - uint64(origin)) / 2; d > 0; d /= 2 {
+			res = append(res, int64(uint64(v)-d))
+		}
+		return res
+	}
+	for d := (uint64(origin) - uint64(v)) / 2; d > 0; d /= 2 {
+		res = append(res, int64(uint64(v)+d))
+	}
+	return res
+}
+
+func floatTree(v, origin float64) Tree[float64] {
+	return Tree[float64]{
+		Value: v,
+		shrink: func() []Tree[float64] {
+			if v == origin {
+				return nil
+			}
+
+			res := []Tree[float64]{floatTree(origin, origin)}
+			// отбрасывание дробной части приближает значение к нулю и может вывести его за origin
+			if t := math.Trunc(v); t != v && (t-origin)*(v-origin) > 0 {
+				res = append(res, floatTree(t, origin))
+			}
+			for d := (v - origin) / 2; math.Abs(d) > 1e-9; d /= 2 {
+				res = append(res, floatTree(v-d, origin))
+			}
+			return res
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package prop
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForAllPasses(t *testing.T) {
+	f := ForAll(Int64(-100, 100), func(v int64) error {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("value %d out of range", v)
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	assert.Nil(t, f)
+}
+
+func TestShrinkInt(t *testing.T) {
+	f := ForAll(Int64(0, 1000000), func(v int64) error {
+		if v >= 1000 {
+			return errors.New("too big")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Equal(t, int64(1000), f.Minimal)
+	assert.GreaterOrEqual(t, f.Original, int64(1000))
+}
+
+func TestShrinkSlice(t *testing.T) {
+	f := ForAll(SliceOf(Int64(0, 100), 0, 20), func(xs []int64) error {
+		for _, x := range xs {
+			if x > 50 {
+				return fmt.Errorf("element %d is greater than 50", x)
+			}
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Equal(t, []int64{51}, f.Minimal)
+	assert.Contains(t, f.String(), "[51]")
+}
+
+func TestShrinkZip(t *testing.T) {
+	f := ForAll(Zip(Int(0, 100), StringOf("abc", 0, 10)), func(p Pair[int, string]) error {
+		if p.First > 10 && strings.Contains(p.Second, "c") {
+			return errors.New("failed")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Equal(t, Pair[int, string]{First: 11, Second: "c"}, f.Minimal)
+	assert.Contains(t, f.String(), `(11, "c")`)
+}
+
+func TestPanicIsFailure(t *testing.T) {
+	f := ForAll(Int(0, 10), func(v int) error {
+		if v > 5 {
+			panic("boom")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Equal(t, 6, f.Minimal)
+	assert.ErrorContains(t, f.Err, "boom")
+}
+
+func TestFilter(t *testing.T) {
+	even := Filter(Int(0, 1000), func(v int) bool { return v%2 == 0 })
+	f := ForAll(even, func(v int) error {
+		if v > 100 {
+			return errors.New("too big")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Equal(t, 102, f.Minimal)
+}
+
+func TestReproducible(t *testing.T) {
+	prop := func(xs []int64) error {
+		if len(xs) > 3 {
+			return errors.New("too long")
+		}
+		return nil
+	}
+	g := SliceOf(Int64(0, 1000), 0, 10)
+
+	a := ForAll(g, prop, WithRand(rand.New(rand.NewSource(42))))
+	b := ForAll(g, prop, WithRand(rand.New(rand.NewSource(42))))
+	require.NotNil(t, a)
+	assert.Equal(t, a, b)
+	assert.Equal(t, []int64{0, 0, 0, 0}, a.Minimal)
+}
+
+func TestShrinkURL(t *testing.T) {
+	f := ForAll(URL(), func(u string) error {
+		if strings.Contains(u, "?") {
+			return errors.New("query is not supported")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	require.NotNil(t, f)
+	assert.Contains(t, f.Minimal, "?")
+	assert.NotContains(t, f.Minimal, "#")
+	assert.NotContains(t, f.Minimal, "@")
+	assert.NotContains(t, f.Minimal, "xn--")
+
+	g := ForAll(URL(), func(u string) error {
+		if strings.Contains(u, "?") {
+			return errors.New("query is not supported")
+		}
+		return nil
+	}, WithRand(rand.New(rand.NewSource(1))))
+	assert.Equal(t, f, g, "the same seed must reproduce the same counterexample")
+
+	original, err := url.Parse(f.Original)
+	require.NoError(t, err)
+	minimal, err := url.Parse(f.Minimal)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(original.RawQuery, minimal.RawQuery),
+		"minimal counterexample %q must be reduction of %q", f.Minimal, f.Original)
+}
+
+func TestShrinkFloat64StaysInRange(t *testing.T) {
+	var walk func(tree Tree[float64], depth int)
+	walk = func(tree Tree[float64], depth int) {
+		require.GreaterOrEqual(t, tree.Value, 1.5)
+		require.LessOrEqual(t, tree.Value, 10.0)
+		if depth == 0 {
+			return
+		}
+		for _, c := range tree.Shrinks() {
+			walk(c, depth-1)
+		}
+	}
+
+	g := Float64(1.5, 10)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		walk(g(r, MaxSize), 2)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.True(t, Check(t, CounterDeltas(20), func(ds []int64) error {
+		var sum int64
+		for _, d := range ds {
+			if d < 0 {
+				return errors.New("negative delta")
+			}
+			sum += d
+		}
+		if sum < 0 {
+			return errors.New("sum overflow")
+		}
+		return nil
+	}))
+
+	for i := 0; i < 100; i++ {
+		_ = Gauge().Sample(rand.New(rand.NewSource(int64(i))))
+	}
+}
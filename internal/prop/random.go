@@ -0,0 +1,39 @@
+package prop
+
+import (
+	"math/rand"
+
+	"github.com/Yandex-Practicum/go-autotests/internal/random"
+)
+
+// URL генерирует валидные URL с произвольным набором особенностей random.NewURL.
+// При уменьшении особенности отключаются по одной, пока URL не станет простейшим.
+// Все уменьшения строятся из тех же случайных значений, что и исходный URL,
+// поэтому отличаются от него только отключенными частями
+func URL() Gen[string] {
+	features := SubsetOf(random.AllURLOptions()...)
+	return func(r *rand.Rand, size int) Tree[string] {
+		tree := features(r, size)
+		seed := r.Int63()
+		return mapTree(tree, func(opts []random.URLOption) string {
+			return random.NewURLWithRand(rand.New(rand.NewSource(seed)), opts...).String()
+		})
+	}
+}
+
+// CounterDeltas генерирует последовательности от 1 до maxLen неотрицательных приращений counter,
+// сумма которых не превышает int64
+func CounterDeltas(maxLen int) Gen[[]int64] {
+	return SliceOf(Int64(0, 1<<40), 1, maxLen)
+}
+
+// Gauge генерирует значения gauge, включая граничные случаи random.GaugeCases
+func Gauge() Gen[float64] {
+	return OneOf(
+		Float64(-1e6, 1e6),
+		From(func(r *rand.Rand) float64 {
+			cases := random.GaugeCasesWithRand(r)
+			return *cases[r.Intn(len(cases))].Updates[0].Value
+		}),
+	)
+}
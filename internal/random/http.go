@@ -1,6 +1,7 @@
 package random
 
 import (
+	mathrand "math/rand"
 	"net/url"
 	"strings"
 )
@@ -21,6 +22,11 @@ func URL() *url.URL {
 
 // Domain returns random valid domain
 func Domain(minLen, maxLen int, zones ...string) string {
+	return domain(rnd, minLen, maxLen, zones...)
+}
+
+// domain returns random valid domain using given generator
+func domain(r *mathrand.Rand, minLen, maxLen int, zones ...string) string {
 	if minLen == 0 {
 		minLen = 5
 	}
@@ -35,12 +41,12 @@ func Domain(minLen, maxLen int, zones ...string) string {
 		zone = zones[0]
 	case 0:
 		zones = []string{"com", "ru", "net", "biz", "yandex"}
-		zone = zones[rnd.Intn(len(zones))]
+		zone = zones[r.Intn(len(zones))]
 	default:
-		zone = zones[rnd.Intn(len(zones))]
+		zone = zones[r.Intn(len(zones))]
 	}
 
 	// generate HOST
-	host := strings.ToLower(ASCIIString(minLen, maxLen))
+	host := strings.ToLower(asciiString(r, minLen, maxLen))
 	return host + "." + strings.TrimLeft(zone, ".")
 }
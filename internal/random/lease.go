@@ -0,0 +1,158 @@
+package random
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// PortLockDirEnv is the name of environment variable overriding directory of port lock files
+const PortLockDirEnv = "AUTOTESTS_PORT_LOCK_DIR"
+
+// errLocked is returned when port is already leased by another process
+var errLocked = errors.New("port is leased by another process")
+
+// PortLease holds a free port until it is handed off to the target or released.
+// While the lease is active the port listening socket is kept open, so no other process could take the port,
+// and the port lock file is held, so concurrently running autotest binaries never lease the same port
+type PortLease struct {
+	port int
+
+	m        sync.Mutex
+	listener net.Listener
+	unlock   func() error
+}
+
+// LeasePort finds free port on localhost and leases it
+func LeasePort() (*PortLease, error) {
+	dir := PortLockDir()
+	if err := mkdirShared(dir); err != nil {
+		return nil, fmt.Errorf("cannot create port lock directory: %w", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		ln, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+
+		unlock, err := lockFile(filepath.Join(dir, strconv.Itoa(port)+".lock"))
+		if errors.Is(err, errLocked) {
+			// port is free now, but another autotest binary is about to bind it
+			_ = ln.Close()
+			continue
+		}
+		if err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("cannot lock port %d: %w", port, err)
+		}
+
+		return &PortLease{
+			port:     port,
+			listener: ln,
+			unlock:   unlock,
+		}, nil
+	}
+	return nil, errors.New("cannot find port not leased by other processes")
+}
+
+// PortLockDir returns directory of port lock files shared by all autotest binaries
+func PortLockDir() string {
+	if dir := os.Getenv(PortLockDirEnv); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "go-autotests-ports")
+}
+
+// sharedFileMode is the mode of port lock files, which are shared by all users of the host
+const sharedFileMode = 0o666
+
+// sharedDirMode is the mode of port lock directory: like os.TempDir, everyone can create files in it
+const sharedDirMode = 0o777 | os.ModeSticky
+
+// mkdirShared creates port lock directory writable by all users.
+// Mode of the directory is set explicitly since umask may strip write permission on creation
+func mkdirShared(dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o777); err != nil {
+		return err
+	}
+	err := os.Mkdir(dir, sharedDirMode)
+	if errors.Is(err, os.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dir, sharedDirMode)
+}
+
+// Port returns leased port
+func (l *PortLease) Port() int {
+	return l.port
+}
+
+// Addr returns leased address in a form of localhost:port
+func (l *PortLease) Addr() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(l.port))
+}
+
+// Listener passes ownership of listening socket to the caller, which becomes responsible for closing it.
+// It is intended for in-process servers. Returns nil if socket is already handed off
+func (l *PortLease) Listener() net.Listener {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	ln := l.listener
+	l.listener = nil
+	return ln
+}
+
+// Handoff closes listening socket so the target process could bind the port.
+// It must be called right before starting the target. The port lock is kept until Release
+func (l *PortLease) Handoff() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.listener == nil {
+		return nil
+	}
+	err := l.listener.Close()
+	l.listener = nil
+	return err
+}
+
+// Release closes listening socket if it is still held and removes the port lock
+func (l *PortLease) Release() error {
+	err := l.Handoff()
+
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.unlock != nil {
+		err = errors.Join(err, l.unlock())
+		l.unlock = nil
+	}
+	return err
+}
+
+// UnusedPort returns random unused port
+//
+// Deprecated: the port is not reserved after return and could be taken by another process,
+// use LeasePort instead
+func UnusedPort() (int, error) {
+	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+
+	l, err := net.ListenTCP("tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,85 @@
+package random
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasePort(t *testing.T) {
+	t.Setenv(PortLockDirEnv, t.TempDir())
+
+	lease, err := LeasePort()
+	require.NoError(t, err)
+	defer lease.Release()
+
+	_, err = net.Listen("tcp", lease.Addr())
+	assert.Error(t, err, "port must be held until handoff")
+
+	require.NoError(t, lease.Handoff())
+	lockPath := filepath.Join(PortLockDir(), strconv.Itoa(lease.Port())+".lock")
+	_, err = lockFile(lockPath)
+	assert.ErrorIs(t, err, errLocked, "port must stay locked after handoff")
+
+	ln, err := net.Listen("tcp", lease.Addr())
+	require.NoError(t, err, "port must be free after handoff")
+	require.NoError(t, ln.Close())
+
+	require.NoError(t, lease.Release())
+	unlock, err := lockFile(lockPath)
+	require.NoError(t, err, "port must be unlocked after release")
+	require.NoError(t, unlock())
+}
+
+func TestLeasePortListener(t *testing.T) {
+	t.Setenv(PortLockDirEnv, t.TempDir())
+
+	lease, err := LeasePort()
+	require.NoError(t, err)
+	defer lease.Release()
+
+	ln := lease.Listener()
+	require.NotNil(t, ln)
+	defer ln.Close()
+	assert.Nil(t, lease.Listener(), "listener must be handed off only once")
+	assert.Equal(t, lease.Port(), ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestLeasePortConcurrent(t *testing.T) {
+	t.Setenv(PortLockDirEnv, t.TempDir())
+
+	var (
+		wg     sync.WaitGroup
+		m      sync.Mutex
+		leases []*PortLease
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := LeasePort()
+			if !assert.NoError(t, err) {
+				return
+			}
+			// handing off immediately makes the port free for the OS, only lock protects it
+			assert.NoError(t, lease.Handoff())
+
+			m.Lock()
+			defer m.Unlock()
+			leases = append(leases, lease)
+		}()
+	}
+	wg.Wait()
+
+	ports := make(map[int]struct{})
+	for _, lease := range leases {
+		assert.NotContains(t, ports, lease.Port())
+		ports[lease.Port()] = struct{}{}
+		assert.NoError(t, lease.Release())
+	}
+}
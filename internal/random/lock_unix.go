@@ -0,0 +1,37 @@
+//go:build !windows
+
+package random
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes exclusive advisory lock of the file at path.
+// The lock is released by the kernel if the process dies, so stale lock files are harmless
+func lockFile(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, sharedFileMode)
+	if err != nil {
+		return nil, err
+	}
+	// umask may strip write permission from the created file, so other users could not lock it.
+	// Only the owner can change the mode, files created by other users are left as is
+	if err := f.Chmod(sharedFileMode); err != nil && !errors.Is(err, os.ErrPermission) {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+
+	return func() error {
+		// lock file is not removed: otherwise other process could lock already unlinked file
+		return f.Close()
+	}, nil
+}
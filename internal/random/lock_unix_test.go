@@ -0,0 +1,34 @@
+//go:build !windows
+
+package random
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeasePortSharedModes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ports")
+	t.Setenv(PortLockDirEnv, dir)
+
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+
+	lease, err := LeasePort()
+	require.NoError(t, err)
+	defer lease.Release()
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, sharedDirMode, info.Mode()&(os.ModePerm|os.ModeSticky), "lock directory must be writable by all users")
+
+	info, err = os.Stat(filepath.Join(dir, strconv.Itoa(lease.Port())+".lock"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(sharedFileMode), info.Mode().Perm(), "lock file must be writable by all users")
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package random
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// staleLockAge is the age after which lock file left by a dead process is removed
+const staleLockAge = 30 * time.Minute
+
+// lockFile creates lock file at path exclusively and removes it on unlock
+func lockFile(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, sharedFileMode)
+	if errors.Is(err, os.ErrExist) {
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < staleLockAge {
+			return nil, errLocked
+		}
+		_ = os.Remove(path)
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, sharedFileMode)
+		if errors.Is(err, os.ErrExist) {
+			return nil, errLocked
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return errors.Join(f.Close(), os.Remove(path))
+	}, nil
+}
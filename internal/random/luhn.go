@@ -0,0 +1,151 @@
+package random
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrNotDigits is returned when Luhn algorithm is applied to a string containing non-digit characters
+var ErrNotDigits = errors.New("string contains non-digit characters")
+
+// LuhnCheckDigit calculates Luhn check digit for digit string of arbitrary length
+func LuhnCheckDigit(digits string) (int, error) {
+	sum, err := luhnSum(digits, true)
+	if err != nil {
+		return 0, err
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// LuhnValid reports whether s is non-empty digit string with valid Luhn check digit
+func LuhnValid(s string) bool {
+	if s == "" {
+		return false
+	}
+	sum, err := luhnSum(s, false)
+	return err == nil && sum%10 == 0
+}
+
+// LuhnNumber returns random valid Luhn number which length including check digit is in range [minLen, maxLen)
+func LuhnNumber(minLen, maxLen int) string {
+	if minLen < 1 {
+		minLen = 1
+	}
+	if maxLen <= minLen {
+		maxLen = minLen + 1
+	}
+
+	n := rnd.Intn(maxLen-minLen) + minLen
+	digits := make([]byte, 0, n)
+	for len(digits) < n-1 {
+		d := rnd.Intn(10)
+		if len(digits) == 0 && d == 0 {
+			continue
+		}
+		digits = append(digits, byte('0'+d))
+	}
+
+	cd, err := LuhnCheckDigit(string(digits))
+	if err != nil {
+		panic(fmt.Sprintf("cannot calculate check digit: %s", err))
+	}
+	return string(digits) + strconv.Itoa(cd)
+}
+
+// LuhnWrongCheckDigit returns random Luhn number which check digit is replaced with another one,
+// so the rest of the number is intact and only the check fails
+func LuhnWrongCheckDigit(minLen, maxLen int) string {
+	number := []byte(LuhnNumber(minLen, maxLen))
+	last := len(number) - 1
+	number[last] = byte('0' + (int(number[last]-'0')+rnd.Intn(9)+1)%10)
+	return string(number)
+}
+
+// LuhnTransposed returns random Luhn number with two adjacent digits swapped.
+// Luhn algorithm detects every such transposition except "09" <-> "90", which is never produced
+func LuhnTransposed(minLen, maxLen int) string {
+	if minLen < 2 {
+		minLen = 2
+	}
+	for {
+		number := []byte(LuhnNumber(minLen, maxLen))
+
+		var candidates []int
+		for i := 0; i < len(number)-1; i++ {
+			a, b := number[i], number[i+1]
+			if a == b || a == '0' && b == '9' || a == '9' && b == '0' {
+				continue
+			}
+			if i == 0 && b == '0' {
+				// number must not start with zero
+				continue
+			}
+			candidates = append(candidates, i)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		i := candidates[rnd.Intn(len(candidates))]
+		number[i], number[i+1] = number[i+1], number[i]
+		return string(number)
+	}
+}
+
+// LuhnNonDigit returns random Luhn number with one interior digit replaced by non-digit character.
+// The first and the last digits are kept, so trimming spaces or parsing a sign
+// cannot turn the result back into a digit string
+func LuhnNonDigit(minLen, maxLen int) string {
+	const chars = "abcXYZ-+. _/#"
+
+	if minLen < 3 {
+		minLen = 3
+	}
+	number := []byte(LuhnNumber(minLen, maxLen))
+	number[1+rnd.Intn(len(number)-2)] = chars[rnd.Intn(len(chars))]
+	return string(number)
+}
+
+// InvalidLuhnNumber is a string which fails Luhn check in a specific way
+type InvalidLuhnNumber struct {
+	// Kind is a short name of the way the number was spoiled
+	Kind string
+	// Number is the spoiled number itself
+	Number string
+}
+
+// InvalidLuhnNumbers returns one number of each invalid kind:
+// wrong check digit, transposed digits, non-digit character and empty string
+func InvalidLuhnNumbers(minLen, maxLen int) []InvalidLuhnNumber {
+	return []InvalidLuhnNumber{
+		{Kind: "wrong_check_digit", Number: LuhnWrongCheckDigit(minLen, maxLen)},
+		{Kind: "transposed_digits", Number: LuhnTransposed(minLen, maxLen)},
+		{Kind: "non_digit", Number: LuhnNonDigit(minLen, maxLen)},
+		{Kind: "empty", Number: ""},
+	}
+}
+
+// luhnSum returns Luhn sum of digits. If check is true, digits are treated as a number without check digit
+func luhnSum(digits string, check bool) (int, error) {
+	var sum int
+
+	double := check
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("%w: %q", ErrNotDigits, digits)
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum, nil
+}
@@ -0,0 +1,63 @@
+package random
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	tests := map[string]int{
+		"":                                0,
+		"7992739871":                      3,
+		"1234567890":                      3,
+		"37828224631000":                  5,
+		"401288888888188":                 1,
+		"123456789012345678901234567890":  9,
+		"9999999999999999999999999999999": 1,
+	}
+	for digits, expected := range tests {
+		cd, err := LuhnCheckDigit(digits)
+		require.NoError(t, err)
+		assert.Equal(t, expected, cd, digits)
+	}
+
+	_, err := LuhnCheckDigit("12a4")
+	assert.ErrorIs(t, err, ErrNotDigits)
+}
+
+func TestLuhnValid(t *testing.T) {
+	assert.True(t, LuhnValid("79927398713"))
+	assert.True(t, LuhnValid("12345678903"))
+	assert.False(t, LuhnValid("12345678902"))
+	assert.False(t, LuhnValid(""))
+	assert.False(t, LuhnValid("7992739871a"))
+}
+
+func TestLuhnGenerators(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		number := LuhnNumber(20, 40)
+		require.True(t, LuhnValid(number), number)
+		require.GreaterOrEqual(t, len(number), 20)
+		require.Less(t, len(number), 40)
+		require.NotEqual(t, byte('0'), number[0])
+
+		for _, invalid := range InvalidLuhnNumbers(2, 20) {
+			require.False(t, LuhnValid(invalid.Number), "%s: %q", invalid.Kind, invalid.Number)
+		}
+
+		nonDigit := LuhnNonDigit(2, 10)
+		require.GreaterOrEqual(t, len(nonDigit), 3)
+		require.True(t, isDigit(nonDigit[0]) && isDigit(nonDigit[len(nonDigit)-1]), nonDigit)
+
+		wrong := LuhnWrongCheckDigit(5, 10)
+		cd, err := LuhnCheckDigit(wrong[:len(wrong)-1])
+		require.NoError(t, err)
+		require.NotEqual(t, cd, int(wrong[len(wrong)-1]-'0'))
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
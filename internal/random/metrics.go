@@ -0,0 +1,219 @@
+package random
+
+import (
+	"math"
+	mathrand "math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Metric types of metrics tracks
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+// Metrics is a metric in JSON format used by metrics tracks
+type Metrics struct {
+	ID    string   `json:"id"`
+	MType string   `json:"type"`
+	Delta *int64   `json:"delta,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+}
+
+// MetricCase is a sequence of updates of a single metric together with its expected text value after all of them
+type MetricCase struct {
+	// Class is a short name of the edge case
+	Class string
+	// Updates are sent one by one in the given order, all of them have the same ID and type
+	Updates []Metrics
+	// Expected is the body expected from /value/{type}/{id} after all updates
+	Expected string
+	// Overflow means the sum of counter updates exceeds int64 range.
+	// Server behaviour is not defined in that case, so Expected is empty
+	Overflow bool
+	// Extreme means the case goes beyond values and IDs of ordinary metrics:
+	// float64 limits, subnormals, negative zero, long and Unicode IDs
+	Extreme bool
+}
+
+// ID returns metric name
+func (c MetricCase) ID() string {
+	return c.Updates[0].ID
+}
+
+// MType returns metric type
+func (c MetricCase) MType() string {
+	return c.Updates[0].MType
+}
+
+// UpdatePaths returns text-path updates in a form of /update/{type}/{id}/{value} with escaped ID
+func (c MetricCase) UpdatePaths() []string {
+	res := make([]string, 0, len(c.Updates))
+	for _, m := range c.Updates {
+		res = append(res, "/update/"+m.MType+"/"+url.PathEscape(m.ID)+"/"+MetricText(m))
+	}
+	return res
+}
+
+// ValuePath returns path of text value request in a form of /value/{type}/{id} with escaped ID
+func (c MetricCase) ValuePath() string {
+	return "/value/" + c.MType() + "/" + url.PathEscape(c.ID())
+}
+
+// GaugeText returns canonical text rendering of gauge value: the shortest decimal representation
+// without exponent which parses back to exactly the same float64
+func GaugeText(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// CounterText returns text rendering of counter value
+func CounterText(d int64) string {
+	return strconv.FormatInt(d, 10)
+}
+
+// MetricText returns text rendering of metric value
+func MetricText(m Metrics) string {
+	switch {
+	case m.Value != nil:
+		return GaugeText(*m.Value)
+	case m.Delta != nil:
+		return CounterText(*m.Delta)
+	}
+	return ""
+}
+
+// GaugeCases returns gauge updates covering numeric edge cases:
+// ordinary, large and small values, negative zero, subnormals and values requiring full float64 precision
+func GaugeCases() []MetricCase {
+	return GaugeCasesWithRand(rnd)
+}
+
+// GaugeCasesWithRand is like GaugeCases, but takes all random values from r
+func GaugeCasesWithRand(r *mathrand.Rand) []MetricCase {
+	// sum is calculated at run time, constant expression would be exactly 0.3
+	a, b := 0.1, 0.2
+
+	values := []struct {
+		class   string
+		value   float64
+		extreme bool
+	}{
+		{"gauge_ordinary", float64(r.Intn(1000000)) + float64(r.Intn(1000))/1000, false},
+		{"gauge_negative", -r.Float64() * 1e6, false},
+		{"gauge_large", (1 + r.Float64()) * math.Pow10(21+r.Intn(50)), false},
+		{"gauge_max", math.MaxFloat64, true},
+		{"gauge_small", float64(r.Intn(999)+1) * 1e-6, false},
+		{"gauge_tiny", (1 + r.Float64()) * math.Pow10(-20-r.Intn(50)), false},
+		{"gauge_negative_zero", math.Copysign(0, -1), true},
+		{"gauge_subnormal", math.SmallestNonzeroFloat64 * float64(r.Intn(1000)+1), true},
+		{"gauge_precision", a + b, false},
+		{"gauge_full_precision", float64(r.Int63n(1<<53)) / float64(int64(1)<<(r.Intn(20)+1)), false},
+	}
+
+	res := make([]MetricCase, 0, len(values)+2)
+	for _, v := range values {
+		c := gaugeCase(v.class, metricID(r, v.class), v.value)
+		c.Extreme = v.extreme
+		res = append(res, c)
+	}
+	res = append(res,
+		extreme(gaugeCase("gauge_long_id", metricID(r, "gauge_long_id")+asciiString(r, 200, 300), r.Float64()*1e6)),
+		extreme(gaugeCase("gauge_unicode_id", unicodeMetricID(r), r.Float64()*1e6)),
+	)
+	return res
+}
+
+// CounterCases returns counter update sequences covering numeric edge cases:
+// ordinary deltas, sums at the int64 boundary, overflow of int64, long and Unicode IDs
+func CounterCases() []MetricCase {
+	first := math.MaxInt64/2 + rnd.Int63n(math.MaxInt64/4)
+
+	return []MetricCase{
+		counterCase("counter_ordinary", metricID(rnd, "counter_ordinary"), int64(rnd.Intn(1024)), int64(rnd.Intn(1024))),
+		counterCase("counter_int64_boundary", metricID(rnd, "counter_int64_boundary"), first, math.MaxInt64-first),
+		counterCase("counter_overflow", metricID(rnd, "counter_overflow"), math.MaxInt64-rnd.Int63n(1000), int64(rnd.Intn(1000)+1000)),
+		extreme(counterCase("counter_long_id", metricID(rnd, "counter_long_id")+ASCIIString(200, 300), int64(rnd.Intn(1024)))),
+		extreme(counterCase("counter_unicode_id", unicodeMetricID(rnd), int64(rnd.Intn(1024)))),
+	}
+}
+
+// Metric returns random metric of given type with ordinary value.
+// Empty type means gauge or counter chosen randomly
+func Metric(mtype string) Metrics {
+	if mtype == "" {
+		mtype = []string{Gauge, Counter}[rnd.Intn(2)]
+	}
+
+	m := Metrics{ID: ASCIIString(5, 15), MType: mtype}
+	switch mtype {
+	case Gauge:
+		v := rnd.Float64() * 1e6
+		m.Value = &v
+	case Counter:
+		d := int64(rnd.Intn(1024))
+		m.Delta = &d
+	}
+	return m
+}
+
+// MetricCases returns all gauge and counter edge cases
+func MetricCases() []MetricCase {
+	return append(GaugeCases(), CounterCases()...)
+}
+
+func gaugeCase(class, id string, v float64) MetricCase {
+	return MetricCase{
+		Class:    class,
+		Updates:  []Metrics{{ID: id, MType: Gauge, Value: &v}},
+		Expected: GaugeText(v),
+	}
+}
+
+// extreme marks case as extreme
+func extreme(c MetricCase) MetricCase {
+	c.Extreme = true
+	return c
+}
+
+func counterCase(class, id string, deltas ...int64) MetricCase {
+	c := MetricCase{Class: class}
+
+	var sum int64
+	for _, d := range deltas {
+		c.Updates = append(c.Updates, Metrics{ID: id, MType: Counter, Delta: &d})
+		if sum > math.MaxInt64-d {
+			c.Overflow = true
+		}
+		sum += d
+	}
+	if !c.Overflow {
+		c.Expected = CounterText(sum)
+	}
+	return c
+}
+
+// metricID returns unique metric name prefixed by camel-cased class
+func metricID(r *mathrand.Rand, class string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(class, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String() + asciiString(r, 5, 10)
+}
+
+// unicodeMetricID returns unique metric name consisting of non-ASCII characters
+func unicodeMetricID(r *mathrand.Rand) string {
+	runes := []rune("абвгдеёжзийклмнопрстуфхцчшщъыьэюяΑΒΓΔΩ数据指标")
+
+	n := r.Intn(10) + 10
+	id := make([]rune, 0, n)
+	for len(id) < n {
+		id = append(id, runes[r.Intn(len(runes))])
+	}
+	return "Метрика" + string(id)
+}
@@ -0,0 +1,90 @@
+package random
+
+import (
+	"math"
+	mathrand "math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGaugeText(t *testing.T) {
+	a, b := 0.1, 0.2
+	tests := map[float64]string{
+		1e21:                  "1000000000000000000000",
+		0.000001:              "0.000001",
+		math.Copysign(0, -1):  "-0",
+		a + b:                 "0.30000000000000004",
+		123.456:               "123.456",
+		-42:                   "-42",
+		5e-324:                "0." + strings.Repeat("0", 323) + "5",
+		float64(1<<53 + 1000): "9007199254741992",
+	}
+	for v, expected := range tests {
+		assert.Equal(t, expected, GaugeText(v))
+	}
+}
+
+func TestMetricCases(t *testing.T) {
+	classes := make(map[string]struct{})
+	for _, c := range MetricCases() {
+		require.NotEmpty(t, c.Updates, c.Class)
+		classes[c.Class] = struct{}{}
+
+		for i, p := range c.UpdatePaths() {
+			u, err := url.Parse(p)
+			require.NoError(t, err, c.Class)
+
+			parts := strings.Split(u.Path, "/")
+			require.Len(t, parts, 5, c.Class)
+			assert.Equal(t, c.ID(), parts[3], c.Class)
+			assert.Equal(t, MetricText(c.Updates[i]), parts[4], c.Class)
+		}
+
+		switch c.MType() {
+		case Gauge:
+			v, err := strconv.ParseFloat(c.Expected, 64)
+			require.NoError(t, err, c.Class)
+			assert.Equal(t, math.Float64bits(*c.Updates[0].Value), math.Float64bits(v), "%s must round-trip through text", c.Class)
+		case Counter:
+			if c.Overflow {
+				assert.Empty(t, c.Expected, c.Class)
+				continue
+			}
+			_, err := strconv.ParseInt(c.Expected, 10, 64)
+			require.NoError(t, err, c.Class)
+		}
+	}
+
+	assert.Contains(t, classes, "gauge_negative_zero")
+	assert.Contains(t, classes, "gauge_subnormal")
+	assert.Contains(t, classes, "counter_overflow")
+
+	for _, c := range MetricCases() {
+		switch c.Class {
+		case "gauge_ordinary", "gauge_precision", "counter_ordinary":
+			assert.False(t, c.Extreme, c.Class)
+		case "gauge_max", "gauge_subnormal", "gauge_negative_zero", "gauge_unicode_id", "counter_long_id":
+			assert.True(t, c.Extreme, c.Class)
+		}
+	}
+
+	for _, c := range CounterCases() {
+		if c.Class == "counter_int64_boundary" {
+			assert.Equal(t, CounterText(math.MaxInt64), c.Expected)
+		}
+		if c.Class == "counter_overflow" {
+			assert.True(t, c.Overflow)
+		}
+	}
+}
+
+func TestGaugeCasesWithRand(t *testing.T) {
+	a := GaugeCasesWithRand(mathrand.New(mathrand.NewSource(1)))
+	b := GaugeCasesWithRand(mathrand.New(mathrand.NewSource(1)))
+	require.Equal(t, a, b, "the same generator state must produce the same cases")
+}
@@ -3,17 +3,141 @@ package random
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"flag"
+	"fmt"
+	"hash/fnv"
 	"io"
 	mathrand "math/rand"
+	"os"
+	"strconv"
 )
 
-// rnd generates new random generator with new source for each binary call
-var rnd = func() *mathrand.Rand {
+// SeedEnv is the name of environment variable holding the seed for pseudo-random generators
+const SeedEnv = "AUTOTESTS_SEED"
+
+var (
+	// seed is used by package generator and all generators created with NewRand
+	seed int64
+	// rnd is package generator, seeded by AUTOTESTS_SEED or random value on each binary call
+	rnd *mathrand.Rand
+)
+
+func init() {
+	s, ok, err := envSeed()
+	if err != nil || !ok {
+		s = cryptoSeed()
+	}
+	SetSeed(s)
+}
+
+// Seed returns the seed currently used by package generators
+func Seed() int64 {
+	return seed
+}
+
+// SetSeed reseeds package generator, so subsequent calls produce the same values for the same seed
+func SetSeed(s int64) {
+	seed = s
+	rnd = mathrand.New(mathrand.NewSource(s))
+}
+
+// InitSeed chooses the seed for the test run and reseeds package generator.
+// Non-nil flagSeed takes priority over AUTOTESTS_SEED environment variable,
+// if both are empty, the seed is chosen randomly
+func InitSeed(flagSeed *int64) (int64, error) {
+	if flagSeed != nil {
+		SetSeed(*flagSeed)
+		return *flagSeed, nil
+	}
+
+	s, ok, err := envSeed()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		s = cryptoSeed()
+	}
+	SetSeed(s)
+	return s, nil
+}
+
+// seedFlag is the value of -random-seed flag, distinguishing explicit zero from absent flag
+type seedFlag struct {
+	value int64
+	set   bool
+}
+
+// String implements flag.Value
+func (f *seedFlag) String() string {
+	if f == nil || !f.set {
+		return ""
+	}
+	return strconv.FormatInt(f.value, 10)
+}
+
+// Set implements flag.Value
+func (f *seedFlag) Set(v string) error {
+	s, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return err
+	}
+	f.value, f.set = s, true
+	return nil
+}
+
+// seedValue returns the flag value or nil if the flag was not set
+func (f *seedFlag) seedValue() *int64 {
+	if !f.set {
+		return nil
+	}
+	return &f.value
+}
+
+var flagSeed seedFlag
+
+// RegisterSeedFlag registers -random-seed flag in flag.CommandLine.
+// It should be called from init function of the test binary
+func RegisterSeedFlag() {
+	flag.Var(&flagSeed, "random-seed", "seed for pseudo-random generators, defaults to "+SeedEnv+" env or random value")
+}
+
+// SetupSeed initializes package generators from -random-seed flag registered by RegisterSeedFlag
+// or AUTOTESTS_SEED environment variable and prints the seed, so the run could be repeated.
+// It must be called from TestMain after flag.Parse. The process exits if the seed cannot be parsed
+func SetupSeed() {
+	seed, err := InitSeed(flagSeed.seedValue())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Не удалось инициализировать генератор псевдослучайных значений: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("random seed: %d (для повторения запуска используйте -random-seed=%d или %s=%d)\n", seed, seed, SeedEnv, seed)
+}
+
+// NewRand returns new generator which sequence depends only on the seed and given scope,
+// so each suite could have its own reproducible generator regardless of other suites
+func NewRand(scope string) *mathrand.Rand {
+	h := fnv.New64a()
+	_, _ = io.WriteString(h, scope)
+	return mathrand.New(mathrand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+func envSeed() (int64, bool, error) {
+	v, ok := os.LookupEnv(SeedEnv)
+	if !ok || v == "" {
+		return 0, false, nil
+	}
+	s, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("cannot parse %s value %q: %w", SeedEnv, v, err)
+	}
+	return s, true, nil
+}
+
+func cryptoSeed() int64 {
 	buf := make([]byte, 8)
 	_, err := io.ReadFull(rand.Reader, buf)
 	if err != nil {
 		panic(err)
 	}
-	src := mathrand.NewSource(int64(binary.LittleEndian.Uint64(buf)))
-	return mathrand.New(src)
-}()
+	return int64(binary.LittleEndian.Uint64(buf))
+}
@@ -0,0 +1,63 @@
+package random
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitSeed(t *testing.T) {
+	defer SetSeed(Seed())
+
+	t.Setenv(SeedEnv, "42")
+	s, err := InitSeed(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), s)
+	first := URL().String()
+
+	flagValue := int64(42)
+	s, err = InitSeed(&flagValue)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), s)
+	assert.Equal(t, first, URL().String(), "same seed must produce same values")
+
+	flagValue = 0
+	s, err = InitSeed(&flagValue)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), s, "zero flag must take priority over environment")
+
+	t.Setenv(SeedEnv, "not a number")
+	_, err = InitSeed(nil)
+	assert.Error(t, err)
+
+	flagValue = 7
+	s, err = InitSeed(&flagValue)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), s, "flag must take priority over invalid environment")
+}
+
+func TestSeedFlag(t *testing.T) {
+	var f seedFlag
+	assert.Nil(t, f.seedValue())
+	assert.Equal(t, "", f.String())
+
+	require.NoError(t, f.Set("0"))
+	require.NotNil(t, f.seedValue(), "explicit zero must be distinguished from absent flag")
+	assert.Equal(t, int64(0), *f.seedValue())
+	assert.Equal(t, "0", f.String())
+
+	assert.Error(t, f.Set("not a number"))
+}
+
+func TestNewRand(t *testing.T) {
+	defer SetSeed(Seed())
+
+	SetSeed(42)
+	first := NewRand("TestIteration1").Int63()
+	assert.Equal(t, first, NewRand("TestIteration1").Int63(), "same scope must produce same values")
+	assert.NotEqual(t, first, NewRand("TestIteration2").Int63(), "different scopes must produce different values")
+
+	SetSeed(43)
+	assert.NotEqual(t, first, NewRand("TestIteration1").Int63(), "different seeds must produce different values")
+}
@@ -1,15 +1,24 @@
 package random
 
+import (
+	mathrand "math/rand"
+)
+
 // ASCIIString generates random ASCII string
 func ASCIIString(minLen, maxLen int) string {
+	return asciiString(rnd, minLen, maxLen)
+}
+
+// asciiString generates random ASCII string using given generator
+func asciiString(r *mathrand.Rand, minLen, maxLen int) string {
 	var letters = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFJHIJKLMNOPQRSTUVWXYZ"
 
-	slen := rnd.Intn(maxLen-minLen) + minLen
+	slen := r.Intn(maxLen-minLen) + minLen
 
 	s := make([]byte, 0, slen)
 	i := 0
 	for len(s) < slen {
-		idx := rnd.Intn(len(letters) - 1)
+		idx := r.Intn(len(letters) - 1)
 		char := letters[idx]
 		if i == 0 && '0' <= char && char <= '9' {
 			continue
@@ -0,0 +1,300 @@
+package random
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// URLOption configures URL generated by NewURL
+type URLOption func(*urlConfig)
+
+type urlConfig struct {
+	scheme          string
+	query           bool
+	fragment        bool
+	port            bool
+	userinfo        bool
+	percentEncoding bool
+	idn             bool
+	minLength       int
+}
+
+// WithScheme sets URL scheme, "http" is used by default
+func WithScheme(scheme string) URLOption {
+	return func(c *urlConfig) {
+		c.scheme = scheme
+	}
+}
+
+// WithQuery adds query string with several parameters, including repeated and empty ones
+func WithQuery() URLOption {
+	return func(c *urlConfig) {
+		c.query = true
+	}
+}
+
+// WithFragment adds fragment part to URL
+func WithFragment() URLOption {
+	return func(c *urlConfig) {
+		c.fragment = true
+	}
+}
+
+// WithPort adds explicit non-default port to URL host
+func WithPort() URLOption {
+	return func(c *urlConfig) {
+		c.port = true
+	}
+}
+
+// WithUserinfo adds user name and password to URL
+func WithUserinfo() URLOption {
+	return func(c *urlConfig) {
+		c.userinfo = true
+	}
+}
+
+// WithPercentEncoding adds percent-encoded bytes to path, query and fragment
+func WithPercentEncoding() URLOption {
+	return func(c *urlConfig) {
+		c.percentEncoding = true
+	}
+}
+
+// WithIDN makes URL host an internationalized domain name in punycode form
+func WithIDN() URLOption {
+	return func(c *urlConfig) {
+		c.idn = true
+	}
+}
+
+// WithMinLength makes URL at least n bytes long by adding path segments
+func WithMinLength(n int) URLOption {
+	return func(c *urlConfig) {
+		c.minLength = n
+	}
+}
+
+// AllURLOptions returns options enabling every URL feature except minimal length
+func AllURLOptions() []URLOption {
+	return []URLOption{
+		WithQuery(),
+		WithFragment(),
+		WithPort(),
+		WithUserinfo(),
+		WithPercentEncoding(),
+		WithIDN(),
+	}
+}
+
+// NewURL returns random valid absolute URL configured by given options.
+// Generated URL is in canonical form: its String method returns exactly the same bytes
+// as were generated, so it could be compared byte-for-byte after round-trip through a service
+func NewURL(opts ...URLOption) *url.URL {
+	return NewURLWithRand(rnd, opts...)
+}
+
+// NewURLWithRand is like NewURL, but takes all random values from r,
+// so the same generator state always produces the same URL.
+// Each part of URL is generated from its own generator derived from r,
+// so for the same state of r disabling a feature removes only the corresponding part
+// and leaves the rest of URL intact
+func NewURLWithRand(r *mathrand.Rand, opts ...URLOption) *url.URL {
+	cfg := urlConfig{scheme: "http"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	parts := make([]*mathrand.Rand, urlPartsCount)
+	for i := range parts {
+		parts[i] = mathrand.New(mathrand.NewSource(r.Int63()))
+	}
+	encoding := func(part int) *mathrand.Rand {
+		if !cfg.percentEncoding {
+			return nil
+		}
+		return parts[part]
+	}
+
+	var b strings.Builder
+	b.WriteString(cfg.scheme + "://")
+
+	if cfg.userinfo {
+		b.WriteString(strings.ToLower(asciiString(parts[urlPartUserinfo], 3, 10)))
+		b.WriteString(":")
+		b.WriteString(asciiString(parts[urlPartUserinfo], 5, 15))
+		b.WriteString("@")
+	}
+
+	if cfg.idn {
+		b.WriteString(idnDomain(parts[urlPartHost]))
+	} else {
+		b.WriteString(domain(parts[urlPartHost], 5, 15))
+	}
+
+	if cfg.port {
+		b.WriteString(":" + strconv.Itoa(port(parts[urlPartPort], 1024, 65535)))
+	}
+
+	segments := parts[urlPartPath].Intn(4) + 1
+	for i := 0; i < segments; i++ {
+		b.WriteString("/" + pathSegment(parts[urlPartPath], encoding(urlPartPathEncoding)))
+	}
+
+	var tail strings.Builder
+	if cfg.query {
+		tail.WriteString("?" + query(parts[urlPartQuery], encoding(urlPartQueryEncoding)))
+	}
+	if cfg.fragment {
+		tail.WriteString("#" + strings.ToLower(asciiString(parts[urlPartFragment], 3, 10)))
+		if enc := encoding(urlPartFragmentEncoding); enc != nil {
+			tail.WriteString("-" + percentEncoded(enc))
+		}
+	}
+
+	for b.Len()+tail.Len() < cfg.minLength {
+		b.WriteString("/" + pathSegment(parts[urlPartPath], encoding(urlPartPathEncoding)))
+	}
+	b.WriteString(tail.String())
+
+	u, err := url.Parse(b.String())
+	if err != nil {
+		panic(fmt.Sprintf("generated invalid URL %q: %s", b.String(), err))
+	}
+	return u
+}
+
+// URL parts having separate generators in NewURLWithRand
+const (
+	urlPartUserinfo = iota
+	urlPartHost
+	urlPartPort
+	urlPartPath
+	urlPartPathEncoding
+	urlPartQuery
+	urlPartQueryEncoding
+	urlPartFragment
+	urlPartFragmentEncoding
+	urlPartsCount
+)
+
+// EdgeCaseURLs returns URLs exercising each feature supported by NewURL separately and all of them at once
+func EdgeCaseURLs() []*url.URL {
+	res := []*url.URL{
+		NewURL(WithQuery()),
+		NewURL(WithFragment()),
+		NewURL(WithPort()),
+		NewURL(WithUserinfo()),
+		NewURL(WithPercentEncoding()),
+		NewURL(WithIDN()),
+		NewURL(WithMinLength(2000)),
+		NewURL(AllURLOptions()...),
+	}
+	return res
+}
+
+// InvalidURLs returns curated list of inputs rejected by url.ParseRequestURI.
+// It is intended for negative tests of services accepting URLs: a service validating
+// input with the standard library must respond to each of them with an error
+func InvalidURLs() []string {
+	return []string{
+		"",
+		" ",
+		"not a url",
+		"example.com",
+		"://example.com",
+		"http//example.com",
+		"ht tp://example.com",
+		"1http://example.com",
+		"http://exa mple.com",
+		"http://example.com:port",
+		"http://[::1",
+		"http://%zz.com",
+		"http://example.com/%zz",
+		"http://example.com/%",
+		"http://example.com/\x00",
+		"http://example.com/\x7f",
+		"\x00http://example.com",
+		"http://example.com\n/path",
+	}
+}
+
+// UnusableURLs returns curated list of inputs accepted by url.ParseRequestURI
+// which nevertheless are not usable absolute HTTP URLs: relative references,
+// URLs without host and URLs with out of range port.
+// Services are not required to reject them, so tests must not assert particular status code
+func UnusableURLs() []string {
+	return []string{
+		"/relative/path",
+		"//example.com/no-scheme",
+		"http://",
+		"http:///path-without-host",
+		"http://example.com:99999",
+	}
+}
+
+// idnDomain returns random internationalized domain name in punycode form
+func idnDomain(r *mathrand.Rand) string {
+	const letters = "абвгдеёжзийклмнопрстуфхцчшщъыьэюя"
+	runes := []rune(letters)
+
+	n := r.Intn(10) + 5
+	label := make([]rune, 0, n)
+	for len(label) < n {
+		label = append(label, runes[r.Intn(len(runes))])
+	}
+
+	zones := []string{"рф", "com", "ru", "москва"}
+	domain, err := idna.Lookup.ToASCII(string(label) + "." + zones[r.Intn(len(zones))])
+	if err != nil {
+		panic(fmt.Sprintf("cannot convert domain to punycode: %s", err))
+	}
+	return domain
+}
+
+// pathSegment returns random path segment.
+// If enc is not nil, percent-encoded bytes taken from enc are added
+func pathSegment(r, enc *mathrand.Rand) string {
+	s := strings.ToLower(asciiString(r, 5, 15))
+	if enc != nil {
+		s += "-" + percentEncoded(enc)
+	}
+	return s
+}
+
+// query returns random query string with repeated and empty parameters.
+// If enc is not nil, parameter with percent-encoded bytes taken from enc is added
+func query(r, enc *mathrand.Rand) string {
+	n := r.Intn(3) + 1
+	params := make([]string, 0, n+2)
+	for i := 0; i < n; i++ {
+		params = append(params, strings.ToLower(asciiString(r, 2, 8))+"="+asciiString(r, 2, 10))
+	}
+
+	key := strings.ToLower(asciiString(r, 2, 8))
+	params = append(params, key+"="+asciiString(r, 2, 10), key+"=")
+	if enc != nil {
+		params = append(params, strings.ToLower(asciiString(enc, 2, 8))+"="+percentEncoded(enc)+"+"+percentEncoded(enc))
+	}
+	return strings.Join(params, "&")
+}
+
+// percentEncoded returns random UTF-8 or reserved characters in percent-encoded form
+func percentEncoded(r *mathrand.Rand) string {
+	const chars = "привет мир/?#&=+%"
+	runes := []rune(chars)
+
+	n := r.Intn(5) + 1
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		for _, c := range []byte(string(runes[r.Intn(len(runes))])) {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
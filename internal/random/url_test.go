@@ -0,0 +1,106 @@
+package random
+
+import (
+	mathrand "math/rand"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewURL(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		u := NewURL(AllURLOptions()...)
+		s := u.String()
+
+		parsed, err := url.Parse(s)
+		require.NoError(t, err)
+		require.Equal(t, s, parsed.String(), "URL must be in canonical form")
+
+		assert.NotEmpty(t, u.RawQuery, s)
+		assert.NotEmpty(t, u.Fragment, s)
+		assert.NotEmpty(t, u.Port(), s)
+		assert.NotNil(t, u.User, s)
+		assert.Contains(t, s, "%", s)
+		assert.Contains(t, u.Hostname(), "xn--", s)
+	}
+}
+
+func TestNewURLWithRand(t *testing.T) {
+	for i := int64(0); i < 100; i++ {
+		a := NewURLWithRand(mathrand.New(mathrand.NewSource(i)), AllURLOptions()...)
+		b := NewURLWithRand(mathrand.New(mathrand.NewSource(i)), AllURLOptions()...)
+		require.Equal(t, a.String(), b.String(), "the same generator state must produce the same URL")
+	}
+}
+
+func TestNewURLWithRandDisableFeature(t *testing.T) {
+	for i := int64(0); i < 100; i++ {
+		full := NewURLWithRand(mathrand.New(mathrand.NewSource(i)), AllURLOptions()...)
+		noQuery := NewURLWithRand(mathrand.New(mathrand.NewSource(i)),
+			WithFragment(), WithPort(), WithUserinfo(), WithPercentEncoding(), WithIDN())
+
+		require.Empty(t, noQuery.RawQuery)
+		full.RawQuery = ""
+		assert.Equal(t, full.String(), noQuery.String(), "disabling query must not change other parts of URL")
+	}
+}
+
+func TestNewURLMinLength(t *testing.T) {
+	u := NewURL(WithMinLength(3000), WithQuery())
+	assert.GreaterOrEqual(t, len(u.String()), 3000)
+}
+
+func TestEdgeCaseURLs(t *testing.T) {
+	for _, u := range EdgeCaseURLs() {
+		s := u.String()
+		parsed, err := url.Parse(s)
+		require.NoError(t, err)
+		assert.Equal(t, s, parsed.String())
+		assert.True(t, isAbsoluteHTTPURL(s), s)
+	}
+}
+
+func TestInvalidURLs(t *testing.T) {
+	for _, s := range InvalidURLs() {
+		_, err := url.ParseRequestURI(s)
+		assert.Error(t, err, "%q must be rejected by url.ParseRequestURI", s)
+	}
+}
+
+func TestUnusableURLs(t *testing.T) {
+	for _, s := range UnusableURLs() {
+		_, err := url.ParseRequestURI(s)
+		assert.NoError(t, err, "%q must be accepted by url.ParseRequestURI", s)
+		assert.False(t, isAbsoluteHTTPURL(s), "%q must not be usable absolute URL", s)
+	}
+}
+
+// isAbsoluteHTTPURL reports whether s is a valid absolute HTTP URL
+func isAbsoluteHTTPURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	if err != nil {
+		return false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host, port := u.Hostname(), u.Port()
+	if host == "" || strings.ContainsAny(host, " %") {
+		return false
+	}
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 || p > 65535 {
+			return false
+		}
+	}
+	if net.ParseIP(host) == nil && strings.Contains(host, ":") {
+		return false
+	}
+	return true
+}
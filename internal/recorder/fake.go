@@ -0,0 +1,79 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewFake запускает на адресе listenAddr поддельный сервер сбора метрик, записывающий все полученные запросы.
+// Сервер принимает обновления метрик по API /update/ и /updates/ и отвечает так же, как это требуется от
+// настоящего сервера. Пустой listenAddr означает свободный порт на localhost
+func NewFake(listenAddr string) (*Recorder, error) {
+	return serve(listenAddr, http.HandlerFunc(fakeMetrics))
+}
+
+// fakeMetrics обрабатывает запросы обновления метрик без их сохранения
+func fakeMetrics(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec := Request{Method: req.Method, URL: req.URL, Header: req.Header}
+	rec.Body, rec.DecodeErr = decodeBody(req.Header, raw)
+
+	path := strings.Trim(req.URL.Path, "/")
+	switch {
+	case path == "update" || path == "updates":
+		updates, err := rec.Updates()
+		if err == nil {
+			err = validateUpdates(updates)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if path == "update" {
+			_ = json.NewEncoder(w).Encode(updates[0].Metric)
+		}
+	case strings.HasPrefix(path, "update/"):
+		// имя метрики не указано
+		if strings.Count(path, "/") != 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := rec.Updates(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// validateUpdates проверяет, что метрики из JSON API имеют известный тип и значение этого типа
+func validateUpdates(updates []Update) error {
+	for _, u := range updates {
+		switch {
+		case u.ID == "":
+			return fmt.Errorf("metric id is empty")
+		case u.MType == "counter" && u.Delta != nil && u.Value == nil:
+		case u.MType == "gauge" && u.Value != nil && u.Delta == nil:
+		default:
+			return fmt.Errorf("invalid metric %q of type %q", u.ID, u.MType)
+		}
+	}
+	return nil
+}
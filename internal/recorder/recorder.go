@@ -0,0 +1,279 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder является HTTP сервером, записывающим все полученные запросы.
+// Запросы передаются обратному прокси или поддельному серверу сбора метрик
+type Recorder struct {
+	listener net.Listener
+	server   *http.Server
+	upstream http.Handler
+
+	m        sync.Mutex
+	requests []*Request
+	changed  chan struct{}
+}
+
+// New запускает прокси на адресе listenAddr, перенаправляющий запросы на target.
+// target может быть URL или адресом вида host:port. Пустой listenAddr означает свободный порт на localhost
+func New(listenAddr, target string) (*Recorder, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse target URL: %w", err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return serve(listenAddr, proxy)
+}
+
+// serve запускает на адресе listenAddr сервер, записывающий запросы и передающий их upstream
+func serve(listenAddr string, upstream http.Handler) (*Recorder, error) {
+	if listenAddr == "" {
+		listenAddr = "localhost:0"
+	}
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		listener: ln,
+		upstream: upstream,
+		changed:  make(chan struct{}),
+	}
+	r.server = &http.Server{Handler: r}
+
+	go func() {
+		_ = r.server.Serve(ln)
+	}()
+	return r, nil
+}
+
+// Addr возвращает адрес, на котором сервер принимает запросы
+func (r *Recorder) Addr() string {
+	return r.listener.Addr().String()
+}
+
+// URL возвращает базовый URL сервера
+func (r *Recorder) URL() string {
+	return "http://" + r.Addr()
+}
+
+// Close останавливает сервер
+func (r *Recorder) Close() error {
+	err := r.server.Close()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP реализует интерфейс http.Handler
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	raw, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+
+	rec := &Request{
+		Time:    time.Now(),
+		Method:  req.Method,
+		URL:     req.URL,
+		Header:  req.Header.Clone(),
+		RawBody: raw,
+	}
+	rec.Body, rec.DecodeErr = decodeBody(req.Header, raw)
+
+	sw := &statusWriter{ResponseWriter: w}
+	r.upstream.ServeHTTP(sw, req)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	rec.Status = sw.status
+	r.requests = append(r.requests, rec)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Requests возвращает копию всех записанных запросов в порядке их получения
+func (r *Recorder) Requests() []Request {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	res := make([]Request, 0, len(r.requests))
+	for _, rec := range r.requests {
+		res = append(res, *rec)
+	}
+	return res
+}
+
+// Reset удаляет все записанные запросы
+func (r *Recorder) Reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.requests = nil
+}
+
+// Updates возвращает все обновления метрик из записанных запросов.
+// Запросы, которые не удалось разобрать, возвращаются в ошибке
+func (r *Recorder) Updates() ([]Update, error) {
+	var (
+		updates []Update
+		errs    []error
+	)
+	for _, req := range r.Requests() {
+		u, err := req.Updates()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", req, err))
+			continue
+		}
+		updates = append(updates, u...)
+	}
+	return updates, errors.Join(errs...)
+}
+
+// UpdatesFor возвращает обновления метрики с именем id в порядке их получения
+func (r *Recorder) UpdatesFor(id string) []Update {
+	updates, _ := r.Updates()
+
+	var res []Update
+	for _, u := range updates {
+		if u.ID == id {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+// Intervals возвращает интервалы между последовательными записанными запросами
+func (r *Recorder) Intervals() []time.Duration {
+	reqs := r.Requests()
+	times := make([]time.Time, 0, len(reqs))
+	for _, req := range reqs {
+		times = append(times, req.Time)
+	}
+	return intervals(times)
+}
+
+// UpdateIntervals возвращает интервалы между последовательными обновлениями метрики с именем id
+func (r *Recorder) UpdateIntervals(id string) []time.Duration {
+	updates := r.UpdatesFor(id)
+	times := make([]time.Time, 0, len(updates))
+	for _, u := range updates {
+		times = append(times, u.Time)
+	}
+	return intervals(times)
+}
+
+// IntervalMismatch описывает интервал, выходящий за допустимые границы
+type IntervalMismatch struct {
+	// Index номер интервала, начиная с нуля
+	Index   int
+	Got     time.Duration
+	Want    time.Duration
+	Allowed time.Duration
+}
+
+func (m IntervalMismatch) String() string {
+	return fmt.Sprintf("интервал %d равен %s, ожидался %s ± %s", m.Index+1, m.Got, m.Want, m.Allowed)
+}
+
+// IntervalMismatches возвращает интервалы, отклоняющиеся от want больше чем на долю tolerance от want
+func IntervalMismatches(intervals []time.Duration, want time.Duration, tolerance float64) []IntervalMismatch {
+	allowed := time.Duration(float64(want) * tolerance)
+	var res []IntervalMismatch
+	for i, got := range intervals {
+		if got < want-allowed || got > want+allowed {
+			res = append(res, IntervalMismatch{Index: i, Got: got, Want: want, Allowed: allowed})
+		}
+	}
+	return res
+}
+
+func intervals(times []time.Time) []time.Duration {
+	if len(times) < 2 {
+		return nil
+	}
+	res := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		res = append(res, times[i].Sub(times[i-1]))
+	}
+	return res
+}
+
+// Wait ожидает, пока записанные запросы не будут удовлетворять условию cond
+func (r *Recorder) Wait(ctx context.Context, cond func(reqs []Request) bool) error {
+	for {
+		r.m.Lock()
+		changed := r.changed
+		r.m.Unlock()
+
+		if cond(r.Requests()) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// WaitUpdates ожидает получения хотя бы n обновлений метрики с именем id
+func (r *Recorder) WaitUpdates(ctx context.Context, id string, n int) ([]Update, error) {
+	var updates []Update
+	err := r.Wait(ctx, func([]Request) bool {
+		updates = r.UpdatesFor(id)
+		return len(updates) >= n
+	})
+	return updates, err
+}
+
+// statusWriter запоминает код статуса ответа
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush реализует интерфейс http.Flusher
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,155 @@
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	var upstream int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream++
+		if strings.HasPrefix(r.URL.Path, "/update/unknown") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rec, err := New("", srv.URL)
+	require.NoError(t, err)
+	defer rec.Close()
+
+	post := func(path, contentType string, body []byte, header http.Header) {
+		req, err := http.NewRequest(http.MethodPost, rec.URL()+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	post("/update/counter/PollCount/3", "text/plain", nil, nil)
+	post("/update/", "application/json", []byte(`{"id":"Alloc","type":"gauge","value":1.5}`), nil)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, err = zw.Write([]byte(`[{"id":"PollCount","type":"counter","delta":2},{"id":"Alloc","type":"gauge","value":2.5}]`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	post("/updates/", "application/json", gz.Bytes(), http.Header{"Content-Encoding": {"gzip"}})
+
+	post("/update/unknown/x/1", "text/plain", nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, rec.Wait(ctx, func(reqs []Request) bool { return len(reqs) == 4 }))
+
+	reqs := rec.Requests()
+	require.Len(t, reqs, 4)
+	assert.Equal(t, 4, upstream)
+	assert.Equal(t, http.StatusOK, reqs[0].Status)
+	assert.Equal(t, "gzip", reqs[2].Header.Get("Content-Encoding"))
+	assert.NotEqual(t, reqs[2].RawBody, reqs[2].Body)
+	assert.Equal(t, http.StatusBadRequest, reqs[3].Status)
+	assert.Len(t, rec.Intervals(), 3)
+
+	updates, err := rec.Updates()
+	assert.Error(t, err, "unknown metric type must be reported")
+	assert.Len(t, updates, 4)
+
+	poll := rec.UpdatesFor("PollCount")
+	require.Len(t, poll, 2)
+	assert.Equal(t, SourcePath, poll[0].Source)
+	assert.Equal(t, int64(3), *poll[0].Delta)
+	assert.Equal(t, SourceBatch, poll[1].Source)
+	assert.Equal(t, int64(2), *poll[1].Delta)
+
+	alloc := rec.UpdatesFor("Alloc")
+	require.Len(t, alloc, 2)
+	assert.Equal(t, SourceJSON, alloc[0].Source)
+	assert.Equal(t, 1.5, *alloc[0].Value)
+	assert.Len(t, rec.UpdateIntervals("Alloc"), 1)
+
+	got, err := rec.WaitUpdates(ctx, "Alloc", 2)
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	rec.Reset()
+	assert.Empty(t, rec.Requests())
+
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = rec.WaitUpdates(short, "Alloc", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFake(t *testing.T) {
+	rec, err := NewFake("")
+	require.NoError(t, err)
+	defer rec.Close()
+
+	tests := []struct {
+		method      string
+		path        string
+		body        string
+		status      int
+		contentType string
+	}{
+		{method: http.MethodPost, path: "/update/counter/PollCount/3", status: http.StatusOK, contentType: "text/plain"},
+		{method: http.MethodPost, path: "/update/gauge/Alloc/1.5", status: http.StatusOK, contentType: "text/plain"},
+		{method: http.MethodPost, path: "/update/gauge/Alloc/none", status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/update/unknown/x/1", status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/update/gauge/", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/update/gauge/Alloc/1.5", status: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/update/", body: `{"id":"Alloc","type":"gauge","value":2.5}`, status: http.StatusOK, contentType: "application/json"},
+		{method: http.MethodPost, path: "/update/", body: `{"id":"Alloc","type":"gauge","delta":2}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/updates/", body: `[{"id":"PollCount","type":"counter","delta":2}]`, status: http.StatusOK, contentType: "application/json"},
+		{method: http.MethodPost, path: "/value/", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, rec.URL()+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.contentType != "" {
+				assert.Contains(t, resp.Header.Get("Content-Type"), tt.contentType)
+			}
+		})
+	}
+
+	reqs := rec.Requests()
+	require.Len(t, reqs, len(tests))
+	assert.Equal(t, http.StatusOK, reqs[0].Status)
+	assert.Equal(t, http.StatusNotFound, reqs[len(reqs)-1].Status)
+	assert.Len(t, rec.UpdatesFor("PollCount"), 2)
+}
+
+func TestIntervalMismatches(t *testing.T) {
+	intervals := []time.Duration{2 * time.Second, 1500 * time.Millisecond, 2600 * time.Millisecond, 2400 * time.Millisecond}
+
+	got := IntervalMismatches(intervals, 2*time.Second, 0.25)
+	require.Len(t, got, 1)
+	assert.Equal(t, IntervalMismatch{Index: 2, Got: 2600 * time.Millisecond, Want: 2 * time.Second, Allowed: 500 * time.Millisecond}, got[0])
+	assert.Equal(t, "интервал 3 равен 2.6s, ожидался 2s ± 500ms", got[0].String())
+
+	assert.Empty(t, IntervalMismatches(intervals, 2*time.Second, 0.5))
+	assert.Empty(t, IntervalMismatches(nil, time.Second, 0))
+}
@@ -0,0 +1,153 @@
+// Package recorder реализует обратный HTTP прокси, который записывает все проходящие
+// через него запросы, чтобы тесты могли проверять, что именно отправил клиент,
+// например агент сбора метрик
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request является записанным HTTP запросом
+type Request struct {
+	// Time время получения запроса
+	Time time.Time
+	// Method метод запроса
+	Method string
+	// URL адрес запроса
+	URL *url.URL
+	// Header заголовки запроса
+	Header http.Header
+	// RawBody тело запроса в том виде, в котором оно было получено
+	RawBody []byte
+	// Body тело запроса после снятия сжатия
+	Body []byte
+	// DecodeErr ошибка снятия сжатия с тела запроса
+	DecodeErr error
+	// Status код статуса ответа сервера или 0, если ответ не был получен
+	Status int
+}
+
+// String возвращает краткое описание запроса
+func (r Request) String() string {
+	return fmt.Sprintf("%s %s %s (%d)", r.Time.Format("15:04:05.000"), r.Method, r.URL.RequestURI(), r.Status)
+}
+
+// Metric описывает метрику в формате JSON API сервера сбора метрик
+type Metric struct {
+	ID    string   `json:"id"`
+	MType string   `json:"type"`
+	Delta *int64   `json:"delta,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+	Hash  string   `json:"hash,omitempty"`
+}
+
+// UpdateSource обозначает способ, которым агент передал значение метрики
+type UpdateSource string
+
+const (
+	// SourcePath значение передано в URL вида /update/{type}/{name}/{value}
+	SourcePath UpdateSource = "path"
+	// SourceJSON значение передано JSON объектом в теле запроса /update/
+	SourceJSON UpdateSource = "json"
+	// SourceBatch значение передано в составе JSON массива в теле запроса /updates/
+	SourceBatch UpdateSource = "batch"
+)
+
+// Update является обновлением значения метрики, найденным в записанном запросе
+type Update struct {
+	Metric
+	// Time время получения запроса
+	Time time.Time
+	// Source способ передачи значения
+	Source UpdateSource
+}
+
+// Updates возвращает обновления метрик, переданные в запросе.
+// Возвращает ошибку, если запрос к API обновления не удалось разобрать
+func (r Request) Updates() ([]Update, error) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case strings.HasPrefix(path, "update/") && strings.Count(path, "/") == 3:
+		return r.pathUpdate(path)
+	case path == "update":
+		var m Metric
+		if err := r.decodeJSON(&m); err != nil {
+			return nil, err
+		}
+		return []Update{{Metric: m, Time: r.Time, Source: SourceJSON}}, nil
+	case path == "updates":
+		var ms []Metric
+		if err := r.decodeJSON(&ms); err != nil {
+			return nil, err
+		}
+		updates := make([]Update, 0, len(ms))
+		for _, m := range ms {
+			updates = append(updates, Update{Metric: m, Time: r.Time, Source: SourceBatch})
+		}
+		return updates, nil
+	}
+	return nil, nil
+}
+
+func (r Request) pathUpdate(path string) ([]Update, error) {
+	parts := strings.Split(path, "/")
+	m := Metric{MType: parts[1], ID: parts[2]}
+
+	switch m.MType {
+	case "counter":
+		delta, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse counter value %q: %w", parts[3], err)
+		}
+		m.Delta = &delta
+	case "gauge":
+		value, err := strconv.ParseFloat(parts[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse gauge value %q: %w", parts[3], err)
+		}
+		m.Value = &value
+	default:
+		return nil, fmt.Errorf("unknown metric type %q", m.MType)
+	}
+	return []Update{{Metric: m, Time: r.Time, Source: SourcePath}}, nil
+}
+
+func (r Request) decodeJSON(v any) error {
+	if r.DecodeErr != nil {
+		return r.DecodeErr
+	}
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("cannot decode JSON body: %w", err)
+	}
+	return nil
+}
+
+// decodeBody снимает с тела запроса сжатие, указанное в заголовке Content-Encoding
+func decodeBody(header http.Header, raw []byte) ([]byte, error) {
+	switch enc := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding"))); enc {
+	case "", "identity":
+		return raw, nil
+	case "gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read gzip body: %w", err)
+		}
+		defer zr.Close()
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read gzip body: %w", err)
+		}
+		return body, nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", enc)
+	}
+}